@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Database struct {
+	URI             string        `yaml:"uri"`
+	Name            string        `yaml:"name"`
+	MaxPoolSize     uint64        `yaml:"max_pool_size"`
+	MinPoolSize     uint64        `yaml:"min_pool_size"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	QueryTimeout    time.Duration `yaml:"query_timeout"`
+}
+
+type JWT struct {
+	Secret          string        `yaml:"secret"`
+	Issuer          string        `yaml:"issuer"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+}
+
+// Mail configures how account emails are delivered. The log driver writes
+// them to Dir, or to the server log when Dir is empty, for local development.
+type Mail struct {
+	Driver   string `yaml:"driver"`
+	From     string `yaml:"from"`
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Dir      string `yaml:"dir"`
+	// AppURL is the web app that links in emails point to.
+	AppURL string `yaml:"app_url"`
+}
+
+type Timeouts struct {
+	Request  time.Duration `yaml:"request"`
+	Read     time.Duration `yaml:"read"`
+	Write    time.Duration `yaml:"write"`
+	Idle     time.Duration `yaml:"idle"`
+	Shutdown time.Duration `yaml:"shutdown"`
+}
+
+type Config struct {
+	Env      string   `yaml:"env"`
+	Port     int      `yaml:"port"`
+	Store    string   `yaml:"store"`
+	Database Database `yaml:"database"`
+	JWT      JWT      `yaml:"jwt"`
+	Mail     Mail     `yaml:"mail"`
+	Timeouts Timeouts `yaml:"timeouts"`
+}
+
+func Default() Config {
+	return Config{
+		Env:      "development",
+		Port:     8080,
+		Store:    "mongo",
+		Database: Database{Name: "petsearch", MaxPoolSize: 50, MinPoolSize: 0, MaxConnIdleTime: 5 * time.Minute, ConnectTimeout: 10 * time.Second, QueryTimeout: 5 * time.Second},
+		JWT:      JWT{Issuer: "pet-search", AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: 30 * 24 * time.Hour},
+		Mail:     Mail{Driver: "log", From: "Pet Search <no-reply@localhost>", Port: 587, AppURL: "http://localhost:3000"},
+		Timeouts: Timeouts{Request: 15 * time.Second, Read: 10 * time.Second, Write: 10 * time.Second, Idle: time.Minute, Shutdown: 10 * time.Second},
+	}
+}
+
+// Load builds the configuration from the defaults, then the YAML file at path
+// (if one is given), then PET_SEARCH_* environment variables, and validates it.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing config file: %w", err)
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	values := map[string]*string{
+		"PET_SEARCH_ENV":         &c.Env,
+		"PET_SEARCH_STORE":       &c.Store,
+		"PET_SEARCH_MONGO_URI":   &c.Database.URI,
+		"PET_SEARCH_DB_NAME":     &c.Database.Name,
+		"PET_SEARCH_JWT_SECRET":  &c.JWT.Secret,
+		"PET_SEARCH_JWT_ISSUER":  &c.JWT.Issuer,
+		"PET_SEARCH_MAIL_DRIVER": &c.Mail.Driver,
+		"PET_SEARCH_MAIL_FROM":   &c.Mail.From,
+		"PET_SEARCH_MAIL_DIR":    &c.Mail.Dir,
+		"PET_SEARCH_SMTP_HOST":   &c.Mail.Host,
+		"PET_SEARCH_SMTP_USER":   &c.Mail.Username,
+		"PET_SEARCH_SMTP_PASS":   &c.Mail.Password,
+		"PET_SEARCH_APP_URL":     &c.Mail.AppURL,
+	}
+	for key, field := range values {
+		if value, ok := os.LookupEnv(key); ok {
+			*field = value
+		}
+	}
+	durations := map[string]*time.Duration{
+		"PET_SEARCH_ACCESS_TOKEN_TTL":  &c.JWT.AccessTokenTTL,
+		"PET_SEARCH_REFRESH_TOKEN_TTL": &c.JWT.RefreshTokenTTL,
+		"PET_SEARCH_CONNECT_TIMEOUT":   &c.Database.ConnectTimeout,
+		"PET_SEARCH_QUERY_TIMEOUT":     &c.Database.QueryTimeout,
+		"PET_SEARCH_REQUEST_TIMEOUT":   &c.Timeouts.Request,
+		"PET_SEARCH_READ_TIMEOUT":      &c.Timeouts.Read,
+		"PET_SEARCH_WRITE_TIMEOUT":     &c.Timeouts.Write,
+		"PET_SEARCH_IDLE_TIMEOUT":      &c.Timeouts.Idle,
+		"PET_SEARCH_SHUTDOWN_TIMEOUT":  &c.Timeouts.Shutdown,
+	}
+	for key, field := range durations {
+		if value, ok := os.LookupEnv(key); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*field = duration
+		}
+	}
+	if value, ok := os.LookupEnv("PET_SEARCH_MAX_POOL_SIZE"); ok {
+		size, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("PET_SEARCH_MAX_POOL_SIZE: %w", err)
+		}
+		c.Database.MaxPoolSize = size
+	}
+	if value, ok := os.LookupEnv("PET_SEARCH_SMTP_PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("PET_SEARCH_SMTP_PORT: %w", err)
+		}
+		c.Mail.Port = port
+	}
+	if value, ok := os.LookupEnv("PET_SEARCH_PORT"); ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("PET_SEARCH_PORT: %w", err)
+		}
+		c.Port = port
+	}
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("port %d is out of range", c.Port))
+	}
+	switch c.Store {
+	case "memory":
+	case "mongo":
+		if c.Database.URI == "" {
+			errs = append(errs, errors.New("database uri is required for the mongo store"))
+		}
+		if c.Database.Name == "" {
+			errs = append(errs, errors.New("database name is required for the mongo store"))
+		}
+		if c.Database.MaxPoolSize == 0 || c.Database.MinPoolSize > c.Database.MaxPoolSize {
+			errs = append(errs, errors.New("database pool size must be positive and at least the minimum"))
+		}
+		if c.Database.ConnectTimeout <= 0 || c.Database.QueryTimeout <= 0 {
+			errs = append(errs, errors.New("database timeouts must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown store %q", c.Store))
+	}
+	if len(c.JWT.Secret) < 32 {
+		errs = append(errs, errors.New("jwt secret must be at least 32 characters"))
+	}
+	if c.JWT.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("jwt access token ttl must be positive"))
+	}
+	if c.JWT.RefreshTokenTTL <= c.JWT.AccessTokenTTL {
+		errs = append(errs, errors.New("jwt refresh token ttl must be longer than the access token ttl"))
+	}
+	switch c.Mail.Driver {
+	case "log":
+		// Emails carry password reset and unlock links, which must not end
+		// up in production server logs.
+		if c.Env == "production" && c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail driver log needs a mail dir in production, or use the smtp driver"))
+		}
+	case "smtp":
+		if c.Mail.Host == "" {
+			errs = append(errs, errors.New("mail host is required for the smtp driver"))
+		}
+		if c.Mail.Port <= 0 || c.Mail.Port > 65535 {
+			errs = append(errs, fmt.Errorf("mail port %d is out of range", c.Mail.Port))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown mail driver %q", c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("mail from address is required"))
+	}
+	if c.Timeouts.Request <= 0 || c.Timeouts.Read <= 0 || c.Timeouts.Write <= 0 || c.Timeouts.Idle <= 0 || c.Timeouts.Shutdown <= 0 {
+		errs = append(errs, errors.New("timeouts must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Port)
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"pet-search-backend-go/models"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	PostCreated      = "post.created"
+	PostLiked        = "post.liked"
+	CommentCreated   = "comment.created"
+	ReplyCreated     = "reply.created"
+	SightingCreated  = "sighting.created"
+	Notification     = "notification"
+	MessageCreated   = "message.created"
+	ConversationRead = "conversation.read"
+	Typing           = "typing"
+	Presence         = "presence"
+)
+
+// Event is a message fanned out to connected clients. A nil Audience means
+// everyone; Post, when set, lets subscribers apply the post's visibility.
+// Transient events, like typing indicators, only matter to live viewers of
+// a post: they are neither buffered for replay nor sent to feed streams.
+type Event struct {
+	ID        uint64
+	Type      string
+	Audience  []primitive.ObjectID
+	Post      *models.Post
+	Data      any
+	Transient bool
+}
+
+// Subscription is a live feed of events. Replay holds the buffered events
+// after the requested Last-Event-ID; Reset is set when the buffer no longer
+// reaches back that far and the client should refetch instead. Events is
+// closed when the subscriber falls too far behind or the hub shuts down.
+type Subscription struct {
+	Replay []Event
+	Reset  bool
+	Events <-chan Event
+	Cancel func()
+}
+
+// Hub fans events out to subscribers. The in-process implementation can be
+// swapped for one backed by a message broker. Subscribe is the feed stream:
+// every non-transient event. SubscribePost only receives the events of one
+// post, transient ones included, so a quiet post's viewers are not crowded
+// out by traffic elsewhere; it has no replay.
+type Hub interface {
+	Publish(event Event)
+	Subscribe(lastEventID uint64) Subscription
+	SubscribePost(postId primitive.ObjectID) Subscription
+	Close()
+}
+
+func (e Event) Addressed(userId primitive.ObjectID) bool {
+	return e.Audience == nil || slices.Contains(e.Audience, userId)
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	replayBuffer     = 1024
+	subscriberBuffer = 64
+)
+
+type memoryHub struct {
+	mu     sync.Mutex
+	nextID uint64
+	buffer []Event
+	// subscribers maps each subscriber to the post it follows, or to
+	// primitive.NilObjectID for the feed.
+	subscribers map[chan Event]primitive.ObjectID
+	closed      bool
+}
+
+// NewMemoryHub returns an in-process hub. Event ids start from the current
+// time so ids handed out before a restart read as older than any new event.
+func NewMemoryHub() Hub {
+	return &memoryHub{nextID: uint64(time.Now().UnixNano()), subscribers: map[chan Event]primitive.ObjectID{}}
+}
+
+func (h *memoryHub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.nextID++
+	event.ID = h.nextID
+	if !event.Transient {
+		h.buffer = append(h.buffer, event)
+		if len(h.buffer) > replayBuffer {
+			h.buffer = h.buffer[len(h.buffer)-replayBuffer:]
+		}
+	}
+	for subscriber, postId := range h.subscribers {
+		if !wants(postId, event) {
+			continue
+		}
+		select {
+		case subscriber <- event:
+		default:
+			// A subscriber that cannot keep up is dropped rather than
+			// slowing everyone down; it resumes from its Last-Event-ID.
+			delete(h.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+// wants reports whether a subscriber following postId gets the event.
+func wants(postId primitive.ObjectID, event Event) bool {
+	if postId.IsZero() {
+		return !event.Transient
+	}
+	return event.Post != nil && event.Post.ID == postId
+}
+
+func (h *memoryHub) subscribe(postId primitive.ObjectID) Subscription {
+	subscriber := make(chan Event, subscriberBuffer)
+	if h.closed {
+		close(subscriber)
+		return Subscription{Events: subscriber, Cancel: func() {}}
+	}
+	h.subscribers[subscriber] = postId
+	return Subscription{Events: subscriber, Cancel: func() { h.cancel(subscriber) }}
+}
+
+func (h *memoryHub) SubscribePost(postId primitive.ObjectID) Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.subscribe(postId)
+}
+
+func (h *memoryHub) Subscribe(lastEventID uint64) Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subscription := h.subscribe(primitive.NilObjectID)
+	if lastEventID == 0 || h.closed {
+		return subscription
+	}
+	oldest := h.nextID + 1
+	if len(h.buffer) > 0 {
+		oldest = h.buffer[0].ID
+	}
+	subscription.Reset = lastEventID+1 < oldest || lastEventID > h.nextID
+	for _, event := range h.buffer {
+		if event.ID > lastEventID {
+			subscription.Replay = append(subscription.Replay, event)
+		}
+	}
+	return subscription
+}
+
+func (h *memoryHub) cancel(subscriber chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[subscriber]; ok {
+		delete(h.subscribers, subscriber)
+		close(subscriber)
+	}
+}
+
+func (h *memoryHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for subscriber := range h.subscribers {
+		delete(h.subscribers, subscriber)
+		close(subscriber)
+	}
+}
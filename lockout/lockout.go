@@ -0,0 +1,140 @@
+// Package lockout slows down password guessing. Each key, such as an account
+// or a client address, has to wait exponentially longer after repeated
+// failures and is locked out for a while once they pile up.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Policy sets how a kind of key is throttled. The first FreeAttempts
+// failures cost nothing; each further one doubles the wait, starting from
+// BaseDelay and capped at MaxDelay. LockAfter failures lock the key for
+// LockFor. Failures are forgotten ResetAfter the last one.
+type Policy struct {
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	LockAfter    int
+	LockFor      time.Duration
+	ResetAfter   time.Duration
+}
+
+var (
+	AccountPolicy = Policy{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Minute, LockAfter: 10, LockFor: 30 * time.Minute, ResetAfter: 24 * time.Hour}
+	// IPPolicy is looser since many people can share an address.
+	IPPolicy = Policy{FreeAttempts: 20, BaseDelay: time.Second, MaxDelay: 5 * time.Minute, LockAfter: 100, LockFor: time.Hour, ResetAfter: 24 * time.Hour}
+	// MailPolicy limits requests that send email to one a minute and five a
+	// day; every request counts, not only failed ones.
+	MailPolicy = Policy{FreeAttempts: 0, BaseDelay: time.Minute, MaxDelay: time.Minute, LockAfter: 5, LockFor: 24 * time.Hour, ResetAfter: 24 * time.Hour}
+)
+
+type Record struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+	// ExpiresAt is when the record stops mattering and may be dropped.
+	ExpiresAt time.Time
+}
+
+// Store keeps a Record per key. Update must apply change atomically, so
+// concurrent attempts are all counted and checked against each other.
+type Store interface {
+	Get(ctx context.Context, key string) (Record, error)
+	Update(ctx context.Context, key string, change func(*Record)) (Record, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// NewLimiter throttles keys under prefix, so one store can hold accounts and
+// addresses side by side.
+func NewLimiter(store Store, prefix string, policy Policy) *Limiter {
+	return &Limiter{store: store, prefix: prefix + ":", policy: policy}
+}
+
+// Reservation is an attempt counted against a key before it is made.
+type Reservation struct {
+	// Wait is how long the key must wait before trying again, and Locked
+	// whether that is because it is locked out. Nothing is reserved when
+	// Wait is positive.
+	Wait   time.Duration
+	Locked bool
+	// Locks reports whether the reserved attempt locked the key out; the
+	// lock stands unless the attempt succeeds and is refunded.
+	Locks  bool
+	Record Record
+}
+
+// Reserve counts an attempt on key as failed before it is made. The check
+// that key may try now and the count happen in one atomic update, so
+// concurrent attempts cannot all slip past the limits together. An attempt
+// that turns out to succeed is taken back with Refund or Reset.
+func (l *Limiter) Reserve(ctx context.Context, key string) (Reservation, error) {
+	now := time.Now()
+	var reservation Reservation
+	record, err := l.store.Update(ctx, l.prefix+key, func(r *Record) {
+		reservation = Reservation{}
+		if wait, locked := l.wait(*r, now); wait > 0 {
+			reservation.Wait, reservation.Locked = wait, locked
+			return
+		}
+		if now.Sub(r.LastFailure) > l.policy.ResetAfter {
+			*r = Record{}
+		}
+		r.Failures++
+		r.LastFailure = now
+		if r.Failures >= l.policy.LockAfter && !now.Before(r.LockedUntil) {
+			r.LockedUntil = now.Add(l.policy.LockFor)
+			reservation.Locks = true
+		}
+		r.ExpiresAt = now.Add(l.policy.ResetAfter)
+		if r.LockedUntil.After(r.ExpiresAt) {
+			r.ExpiresAt = r.LockedUntil
+		}
+	})
+	reservation.Record = record
+	return reservation, err
+}
+
+// Refund takes back one reserved attempt on key, lifting the lock if that
+// attempt was what caused it.
+func (l *Limiter) Refund(ctx context.Context, key string) error {
+	_, err := l.store.Update(ctx, l.prefix+key, func(r *Record) {
+		if r.Failures == 0 {
+			return
+		}
+		r.Failures--
+		if r.Failures < l.policy.LockAfter {
+			r.LockedUntil = time.Time{}
+		}
+	})
+	return err
+}
+
+// Reset forgets the failures of key, after a successful login or an unlock.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Delete(ctx, l.prefix+key)
+}
+
+func (l *Limiter) wait(record Record, now time.Time) (time.Duration, bool) {
+	if now.Before(record.LockedUntil) {
+		return record.LockedUntil.Sub(now), true
+	}
+	if record.Failures <= l.policy.FreeAttempts || now.Sub(record.LastFailure) > l.policy.ResetAfter {
+		return 0, false
+	}
+	delay := l.policy.BaseDelay << min(record.Failures-l.policy.FreeAttempts-1, 30)
+	if delay <= 0 || delay > l.policy.MaxDelay {
+		delay = l.policy.MaxDelay
+	}
+	if wait := record.LastFailure.Add(delay).Sub(now); wait > 0 {
+		return wait, false
+	}
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// logMailer stands in for SMTP during development and tests: each email is
+// written to its own .eml file in dir, or to the server log if dir is empty.
+type logMailer struct {
+	dir  string
+	from *mail.Address
+}
+
+func (m *logMailer) Send(ctx context.Context, message Message) error {
+	to, data, err := compose(m.from, message)
+	if err != nil {
+		return err
+	}
+	if m.dir == "" {
+		log.Printf("mail to %s:\n%s", to.Address, data)
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.Map(fileSafe, to.Address))
+	return os.WriteFile(filepath.Join(m.dir, name), data, 0o600)
+}
+
+// fileSafe keeps recipient addresses from reaching outside dir when used as
+// part of a file name.
+func fileSafe(r rune) rune {
+	if r == '@' || r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+		return r
+	}
+	return '_'
+}
@@ -0,0 +1,61 @@
+// Package mail delivers account emails such as password resets. Handlers
+// depend on the Mailer interface; which implementation runs is chosen by the
+// mail driver in the configuration.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"pet-search-backend-go/config"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+func New(cfg config.Mail) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("mail from address: %w", err)
+	}
+	switch cfg.Driver {
+	case "smtp":
+		return &smtpMailer{cfg: cfg, from: from}, nil
+	case "log":
+		return &logMailer{dir: cfg.Dir, from: from}, nil
+	}
+	return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+}
+
+// compose renders message as a plain-text RFC 5322 email. Header values come
+// from user input, so line breaks in them are refused rather than letting
+// them smuggle extra headers in.
+func compose(from *mail.Address, message Message) (*mail.Address, []byte, error) {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mail recipient: %w", err)
+	}
+	if strings.ContainsAny(message.Subject, "\r\n") {
+		return nil, nil, errors.New("mail subject contains a line break")
+	}
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buffer, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buffer.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return to, buffer.Bytes(), nil
+}
@@ -1,13 +1,89 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"pet-search-backend-go/config"
+	"pet-search-backend-go/db"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/lockout"
+	"pet-search-backend-go/mail"
+	"pet-search-backend-go/middleware"
+	"pet-search-backend-go/models"
 	"pet-search-backend-go/routes"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
+	configPath := flag.String("config", os.Getenv("PET_SEARCH_CONFIG"), "path to a YAML config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	var store models.Store
+	var client *mongo.Client
+	if cfg.Store == "memory" {
+		store = models.NewMemoryStore()
+	} else {
+		client, err = db.Connect(cfg.Database)
+		if err != nil {
+			log.Fatalf("Could not connect to database: %v", err)
+		}
+		store = models.NewMongoStore(client.Database(cfg.Database.Name))
+	}
+	if err := store.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Could not create database indexes: %v", err)
+	}
+
+	mailer, err := mail.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Could not set up mail: %v", err)
+	}
+
 	server := gin.Default()
-	routes.RegisterRoutes(server)
-	server.Run(":8080")
-}
\ No newline at end of file
+	server.ContextWithFallback = true
+	server.Use(middleware.Timeout(cfg.Timeouts.Request))
+	hub := events.NewMemoryHub()
+	routes.RegisterRoutes(server, store, hub, mailer, lockout.NewMemoryStore(), cfg)
+
+	httpServer := &http.Server{
+		Addr:         cfg.Addr(),
+		Handler:      server,
+		ReadTimeout:  cfg.Timeouts.Read,
+		WriteTimeout: cfg.Timeouts.Write,
+		IdleTimeout:  cfg.Timeouts.Idle,
+	}
+	// Shutdown waits for open connections, so end the event streams first.
+	httpServer.RegisterOnShutdown(hub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Timeouts.Shutdown)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	if client != nil {
+		if err := db.Disconnect(shutdownCtx, client); err != nil {
+			log.Printf("Database disconnect: %v", err)
+		}
+	}
+}
@@ -2,25 +2,70 @@ package middleware
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"pet-search-backend-go/models"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const secretKey = "pet-search-api-secret"
+// Authenticate accepts a signed access token only while the session it was
+// issued for is still active, so logging out revokes it immediately rather
+// than when it expires.
+func Authenticate(secretKey string, sessions models.SessionStore, tickets models.AccountTokenStore) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		var userId, sessionId string
+		var ok bool
+		// Browsers cannot set headers on EventSource or WebSocket requests, so
+		// they pass a stream ticket in the URL instead of the access token. It
+		// is spent on first use, so the copy in the request log is worthless.
+		// Only the stream routes take one.
+		if context.Request.Header.Get("Authorization") == "" && IsStreamRoute(context) && context.Query("ticket") != "" {
+			userId, sessionId, ok = ticketClaims(context, tickets)
+		} else {
+			userId, sessionId, ok = tokenClaims(context, secretKey)
+		}
+		if !ok {
+			context.Abort()
+			return
+		}
+		session, active, err := activeSession(context, sessions, userId, sessionId)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not verify session"})
+			context.Abort()
+			return
+		}
+		if !active {
+			context.JSON(http.StatusUnauthorized, gin.H{"message": "Session has ended, please log in again", "error": "session"})
+			context.Abort()
+			return
+		}
+		if session.Stale(time.Now()) || session.IP != context.ClientIP() {
+			if err := sessions.TouchSession(context, session.ID, context.ClientIP()); err != nil {
+				log.Printf("could not update last seen of session %s: %v", sessionId, err)
+			}
+		}
+		context.Request.Header.Set("userId", userId)
+		context.Request.Header.Set("sessionId", sessionId)
+		context.Next()
+	}
+}
 
-func Authenticate(context *gin.Context) {
+// tokenClaims reads the user and session from the bearer access token.
+func tokenClaims(context *gin.Context, secretKey string) (string, string, bool) {
 	authHeader := context.Request.Header.Get("Authorization")
 	if authHeader == "" {
 		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password", "error": "authheader"})
-		context.Abort()
+		return "", "", false
 	}
-	token := strings.Split(authHeader, " ")[1]
+	token, _ := strings.CutPrefix(authHeader, "Bearer ")
 	if token == "" {
 		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password", "error": "token"})
-		context.Abort()
+		return "", "", false
 	}
 	decodedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -30,13 +75,44 @@ func Authenticate(context *gin.Context) {
 	})
 	if err != nil {
 		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password", "error": "decoded"})
-		context.Abort()
+		return "", "", false
 	}
-	if claims, ok := decodedToken.Claims.(jwt.MapClaims); ok {
-		context.Request.Header.Set("userId", claims["sub"].(string))
-		context.Next()
-	} else {
+	claims, ok := decodedToken.Claims.(jwt.MapClaims)
+	if !ok {
 		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid email or password", "error": "claims"})
-		context.Abort()
+		return "", "", false
+	}
+	userId, _ := claims["sub"].(string)
+	sessionId, _ := claims["sid"].(string)
+	return userId, sessionId, true
+}
+
+// ticketClaims spends the stream ticket in the query and reads the user and
+// session it was issued for.
+func ticketClaims(context *gin.Context, tickets models.AccountTokenStore) (string, string, bool) {
+	ticket, err := tickets.ConsumeAccountToken(context, models.TokenStreamTicket, models.HashToken(context.Query("ticket")))
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Stream ticket is invalid or expired", "error": "ticket"})
+		return "", "", false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not verify session"})
+		return "", "", false
+	}
+	return ticket.UserID.Hex(), ticket.SessionID.Hex(), true
+}
+
+func activeSession(context *gin.Context, sessions models.SessionStore, userId, sessionId string) (models.Session, bool, error) {
+	id, err := primitive.ObjectIDFromHex(sessionId)
+	if err != nil {
+		return models.Session{}, false, nil
+	}
+	session, err := sessions.FindSession(context, id)
+	if err == models.ErrNotFound {
+		return models.Session{}, false, nil
+	}
+	if err != nil {
+		return models.Session{}, false, err
 	}
+	return session, session.UserID.Hex() == userId && session.Active(time.Now()), nil
 }
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeout puts a deadline on the request context. The engine must have
+// ContextWithFallback enabled so the deadline reaches store calls made with
+// the gin.Context. Long-lived event streams are left without a deadline.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsStreamRoute(c) {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// streamRoutes are the routes that hold their connection open for as long as
+// the client stays.
+var streamRoutes = []string{"/stream", "/feed/posts/:postId/live"}
+
+// IsStreamRoute reports whether the request matched one of the event stream
+// routes. It goes by the route, not by headers, which any client can send.
+func IsStreamRoute(c *gin.Context) bool {
+	return slices.Contains(streamRoutes, c.FullPath())
+}
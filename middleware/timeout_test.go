@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeoutSkipsOnlyStreamRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	server.Use(Timeout(time.Minute))
+	hasDeadline := func(c *gin.Context) {
+		_, ok := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"deadline": ok})
+	}
+	server.GET("/stream", hasDeadline)
+	server.GET("/feed/posts/:postId/live", hasDeadline)
+	server.GET("/feed/posts/", hasDeadline)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		value  string
+		want   string
+	}{
+		{"event stream", "/stream", "Accept", "text/event-stream", `{"deadline":false}`},
+		{"live socket", "/feed/posts/abc/live", "Upgrade", "websocket", `{"deadline":false}`},
+		{"plain request", "/feed/posts/", "", "", `{"deadline":true}`},
+		{"stream accept header", "/feed/posts/", "Accept", "text/event-stream", `{"deadline":true}`},
+		{"upgrade header", "/feed/posts/", "Upgrade", "websocket", `{"deadline":true}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, test.path, nil)
+			if test.header != "" {
+				request.Header.Set(test.header, test.value)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+			if recorder.Body.String() != test.want {
+				t.Errorf("body = %s, want %s", recorder.Body, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	MinPasswordLength = 10
+	// maxPasswordBytes is where bcrypt stops reading; anything longer would
+	// silently be ignored.
+	maxPasswordBytes = 72
+	maxEmailLength   = 254
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{2,29}$`)
+	// phonePattern is E.164: a plus, a country code that does not start with
+	// zero, and at most 15 digits in all.
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+// DuplicateError is returned when adding a user whose email or username is
+// already taken.
+type DuplicateError struct {
+	Field string
+}
+
+func (e DuplicateError) Error() string {
+	return e.Field + " is already taken"
+}
+
+// Normalize trims the account fields, lowercases the email and strips the
+// separators people type into phone numbers.
+func (u *User) Normalize() {
+	u.Username = strings.TrimSpace(u.Username)
+	u.Email = NormalizeEmail(u.Email)
+	u.PhoneNumber = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -.()", r) {
+			return -1
+		}
+		return r
+	}, u.PhoneNumber)
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Validate checks the account fields of a normalized user; the password is
+// checked separately by ValidatePassword before it is hashed.
+func (u User) Validate() error {
+	var errs []error
+	if !usernamePattern.MatchString(u.Username) {
+		errs = append(errs, errors.New("username must be 3 to 30 letters, digits, dots, dashes or underscores, starting with a letter or digit"))
+	}
+	if !validEmail(u.Email) {
+		errs = append(errs, errors.New("email must be a valid address"))
+	}
+	if u.PhoneNumber != "" && !phonePattern.MatchString(u.PhoneNumber) {
+		errs = append(errs, errors.New("phone number must be in international format, such as +14155552671"))
+	}
+	return errors.Join(errs...)
+}
+
+func validEmail(email string) bool {
+	if len(email) > maxEmailLength {
+		return false
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return false
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return strings.Contains(domain, ".") && !strings.HasSuffix(domain, ".")
+}
+
+// ValidatePassword asks for a long password that mixes letters with digits
+// or symbols and is not just the user's name or email.
+func ValidatePassword(password string, user User) error {
+	var errs []error
+	if len([]rune(password)) < MinPasswordLength {
+		errs = append(errs, fmt.Errorf("password must be at least %d characters", MinPasswordLength))
+	}
+	if len(password) > maxPasswordBytes {
+		errs = append(errs, fmt.Errorf("password must be at most %d bytes", maxPasswordBytes))
+	}
+	hasLetter := strings.IndexFunc(password, unicode.IsLetter) >= 0
+	hasOther := strings.IndexFunc(password, func(r rune) bool { return !unicode.IsLetter(r) }) >= 0
+	if !hasLetter || !hasOther {
+		errs = append(errs, errors.New("password must contain letters and at least one digit or symbol"))
+	}
+	lowered := strings.ToLower(password)
+	localPart, _, _ := strings.Cut(user.Email, "@")
+	for _, personal := range []string{strings.ToLower(user.Username), localPart} {
+		if len(personal) >= 3 && strings.Contains(lowered, personal) {
+			errs = append(errs, errors.New("password must not contain your username or email"))
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
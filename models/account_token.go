@@ -0,0 +1,66 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TokenPasswordReset     = "password_reset"
+	TokenEmailVerification = "email_verification"
+	TokenAccountUnlock     = "account_unlock"
+	TokenStreamTicket      = "stream_ticket"
+
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 48 * time.Hour
+	AccountUnlockTTL     = time.Hour
+	StreamTicketTTL      = 30 * time.Second
+)
+
+// AccountToken is a single-use secret emailed to a user to prove they own
+// the address, or handed to a signed-in client to open an event stream with.
+// Only its hash is stored.
+type AccountToken struct {
+	ID        primitive.ObjectID `bson:"_id" json:"_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	SessionID primitive.ObjectID `bson:"session_id,omitempty" json:"-"`
+	Purpose   string             `bson:"purpose" json:"purpose"`
+	Hash      string             `bson:"hash" json:"-"`
+	ExpiresAt time.Time          `bson:"expires_at" json:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty" json:"used_at,omitempty"`
+	RevokedAt *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type AccountTokenStore interface {
+	// CreateAccountToken stores token and revokes the user's earlier unused
+	// tokens for the same purpose, so only the latest email works. Revoked
+	// tokens are kept until they expire so RecentAccountTokens can count them.
+	// Stream tickets are exempt: a client may open several streams at once.
+	CreateAccountToken(ctx context.Context, token AccountToken) (AccountToken, error)
+	// RecentAccountTokens returns the tokens issued to the user for purpose
+	// since the given time, newest first.
+	RecentAccountTokens(ctx context.Context, userId primitive.ObjectID, purpose string, since time.Time) ([]AccountToken, error)
+	// FindAccountToken returns the unused, unexpired token with hash without
+	// spending it, or returns ErrNotFound.
+	FindAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error)
+	// ConsumeAccountToken marks the unused, unexpired token with hash as used
+	// and returns it, or returns ErrNotFound.
+	ConsumeAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func newAccountToken(t AccountToken) AccountToken {
+	return AccountToken{ID: primitive.NewObjectID(), UserID: t.UserID, SessionID: t.SessionID, Purpose: t.Purpose, Hash: t.Hash, ExpiresAt: t.ExpiresAt, CreatedAt: time.Now()}
+}
+
+// supersedes reports whether issuing the token revokes the earlier ones.
+func (t AccountToken) supersedes() bool {
+	return t.Purpose != TokenStreamTicket
+}
+
+func (t AccountToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
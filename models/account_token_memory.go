@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryAccountTokenStore struct {
+	mu     sync.Mutex
+	tokens []AccountToken
+}
+
+func (s *memoryAccountTokenStore) CreateAccountToken(ctx context.Context, token AccountToken) (AccountToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created := newAccountToken(token)
+	for index, t := range s.tokens {
+		if created.supersedes() && t.UserID == created.UserID && t.Purpose == created.Purpose && t.UsedAt == nil && t.RevokedAt == nil {
+			s.tokens[index].RevokedAt = &created.CreatedAt
+		}
+	}
+	s.tokens = append(s.tokens, clone(created))
+	return created, nil
+}
+
+func (s *memoryAccountTokenStore) RecentAccountTokens(ctx context.Context, userId primitive.ObjectID, purpose string, since time.Time) ([]AccountToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tokens []AccountToken
+	for index := len(s.tokens) - 1; index >= 0; index-- {
+		t := s.tokens[index]
+		if t.UserID == userId && t.Purpose == purpose && !t.CreatedAt.Before(since) {
+			tokens = append(tokens, clone(t))
+		}
+	}
+	return tokens, nil
+}
+
+func (s *memoryAccountTokenStore) FindAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, t := range s.tokens {
+		if t.Purpose == purpose && t.Hash == hash && t.Usable(now) {
+			return clone(t), nil
+		}
+	}
+	return AccountToken{}, ErrNotFound
+}
+
+func (s *memoryAccountTokenStore) ConsumeAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for index, t := range s.tokens {
+		if t.Purpose == purpose && t.Hash == hash && t.Usable(now) {
+			s.tokens[index].UsedAt = &now
+			return clone(s.tokens[index]), nil
+		}
+	}
+	return AccountToken{}, ErrNotFound
+}
+
+func (s *memoryAccountTokenStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAccountTokenStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoAccountTokenStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (s *mongoAccountTokenStore) CreateAccountToken(ctx context.Context, token AccountToken) (AccountToken, error) {
+	created := newAccountToken(token)
+	if created.supersedes() {
+		if err := s.revokeUnused(ctx, created); err != nil {
+			return AccountToken{}, err
+		}
+	}
+	if _, err := s.collection.InsertOne(ctx, created); err != nil {
+		return AccountToken{}, err
+	}
+	return created, nil
+}
+
+func (s *mongoAccountTokenStore) revokeUnused(ctx context.Context, created AccountToken) error {
+	unused := bson.D{
+		{Key: "user_id", Value: created.UserID},
+		{Key: "purpose", Value: created.Purpose},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	revoke := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: created.CreatedAt}}}}
+	_, err := s.collection.UpdateMany(ctx, unused, revoke)
+	return err
+}
+
+func (s *mongoAccountTokenStore) RecentAccountTokens(ctx context.Context, userId primitive.ObjectID, purpose string, since time.Time) ([]AccountToken, error) {
+	filter := bson.D{
+		{Key: "user_id", Value: userId},
+		{Key: "purpose", Value: purpose},
+		{Key: "created_at", Value: bson.D{{Key: "$gte", Value: since}}},
+	}
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return []AccountToken{}, err
+	}
+	var tokens []AccountToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return []AccountToken{}, err
+	}
+	return tokens, nil
+}
+
+// ConsumeAccountToken claims the token in one update, so two requests racing
+// with the same token cannot both succeed.
+// usableToken matches the unused, unexpired token with hash.
+func usableToken(purpose, hash string, now time.Time) bson.D {
+	return bson.D{
+		{Key: "hash", Value: hash},
+		{Key: "purpose", Value: purpose},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+}
+
+func (s *mongoAccountTokenStore) FindAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error) {
+	var token AccountToken
+	err := s.collection.FindOne(ctx, usableToken(purpose, hash, time.Now())).Decode(&token)
+	if err != nil {
+		return AccountToken{}, notFound(err)
+	}
+	return token, nil
+}
+
+func (s *mongoAccountTokenStore) ConsumeAccountToken(ctx context.Context, purpose, hash string) (AccountToken, error) {
+	now := time.Now()
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: now}}}}
+	var token AccountToken
+	err := s.collection.FindOneAndUpdate(ctx, usableToken(purpose, hash, now), update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&token)
+	if err != nil {
+		return AccountToken{}, notFound(err)
+	}
+	return token, nil
+}
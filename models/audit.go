@@ -0,0 +1,55 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AuditAccountLocked   = "account.locked"
+	AuditAddressLocked   = "address.locked"
+	AuditAccountUnlocked = "account.unlocked"
+	AuditTwoFactorOn     = "two_factor.enabled"
+	AuditTwoFactorOff    = "two_factor.disabled"
+	AuditTwoFactorPolicy = "two_factor.required"
+)
+
+const MaxAuditPage = 200
+
+// AuditEvent records a security-relevant happening for admins to review.
+// Email and IP are what the request carried, so they are kept even when no
+// account matched.
+type AuditEvent struct {
+	ID        primitive.ObjectID `bson:"_id" json:"_id"`
+	Type      string             `bson:"type" json:"type"`
+	UserID    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id,omitempty"`
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	IP        string             `bson:"ip,omitempty" json:"ip,omitempty"`
+	Detail    string             `bson:"detail,omitempty" json:"detail,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type AuditFilter struct {
+	Type   string
+	UserID primitive.ObjectID
+	Limit  int
+}
+
+type AuditStore interface {
+	RecordAuditEvent(ctx context.Context, event AuditEvent) error
+	// FindAuditEvents returns the newest matching events first.
+	FindAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func newAuditEvent(e AuditEvent) AuditEvent {
+	e.ID = primitive.NewObjectID()
+	e.CreatedAt = time.Now()
+	return e
+}
+
+func (f AuditFilter) matches(e AuditEvent) bool {
+	return (f.Type == "" || e.Type == f.Type) && (f.UserID.IsZero() || e.UserID == f.UserID)
+}
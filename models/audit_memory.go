@@ -0,0 +1,34 @@
+package models
+
+import (
+	"context"
+	"sync"
+)
+
+type memoryAuditStore struct {
+	mu     sync.RWMutex
+	events []AuditEvent
+}
+
+func (s *memoryAuditStore) RecordAuditEvent(ctx context.Context, event AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, clone(newAuditEvent(event)))
+	return nil
+}
+
+func (s *memoryAuditStore) FindAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []AuditEvent
+	for index := len(s.events) - 1; index >= 0 && len(events) < filter.Limit; index-- {
+		if filter.matches(s.events[index]) {
+			events = append(events, clone(s.events[index]))
+		}
+	}
+	return events, nil
+}
+
+func (s *memoryAuditStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAuditStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoAuditStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	return err
+}
+
+func (s *mongoAuditStore) RecordAuditEvent(ctx context.Context, event AuditEvent) error {
+	_, err := s.collection.InsertOne(ctx, newAuditEvent(event))
+	return err
+}
+
+func (s *mongoAuditStore) FindAuditEvents(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	query := bson.D{}
+	if filter.Type != "" {
+		query = append(query, bson.E{Key: "type", Value: filter.Type})
+	}
+	if !filter.UserID.IsZero() {
+		query = append(query, bson.E{Key: "user_id", Value: filter.UserID})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(filter.Limit))
+	cursor, err := s.collection.Find(ctx, query, opts)
+	if err != nil {
+		return []AuditEvent{}, err
+	}
+	var events []AuditEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return []AuditEvent{}, err
+	}
+	return events, nil
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const MaxMessageLength = 2000
+
+// Participant is one side of a conversation. LastReadAt doubles as the read
+// receipt the other side sees; SharesContact records whether this side has
+// agreed to reveal their email and phone number.
+type Participant struct {
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	LastReadAt    time.Time          `bson:"last_read_at" json:"last_read_at"`
+	SharesContact bool               `bson:"shares_contact" json:"shares_contact"`
+}
+
+type Message struct {
+	ID        primitive.ObjectID `bson:"_id" json:"_id"`
+	Sender    primitive.ObjectID `bson:"sender" json:"sender"`
+	Content   string             `bson:"content" json:"content"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// Conversation is a private 1:1 thread about a report, between the report's
+// author and someone who responded to it.
+type Conversation struct {
+	ID           primitive.ObjectID `bson:"_id" json:"_id"`
+	PostID       primitive.ObjectID `bson:"post_id" json:"post_id"`
+	Participants []Participant      `bson:"participants" json:"participants"`
+	Messages     []Message          `bson:"messages" json:"messages"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+	Version      int64              `bson:"version" json:"-"`
+}
+
+type ConversationSummary struct {
+	ID           primitive.ObjectID `json:"_id"`
+	PostID       primitive.ObjectID `json:"post_id"`
+	Participants []Participant      `json:"participants"`
+	LastMessage  *Message           `json:"last_message,omitempty"`
+	Unread       int                `json:"unread"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+type ConversationStore interface {
+	FindConversations(ctx context.Context, userId primitive.ObjectID) ([]Conversation, error)
+	FindConversation(ctx context.Context, conversationId primitive.ObjectID) (Conversation, error)
+	FindPostConversation(ctx context.Context, postId, userId, otherId primitive.ObjectID) (Conversation, error)
+	CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error)
+	AddMessage(ctx context.Context, conversationId primitive.ObjectID, message Message) (Conversation, error)
+	MarkConversationRead(ctx context.Context, conversationId, userId primitive.ObjectID) (Conversation, error)
+	SetContactSharing(ctx context.Context, conversationId, userId primitive.ObjectID, share bool) (Conversation, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func newConversation(c Conversation) Conversation {
+	now := time.Now()
+	var participants []Participant
+	for _, p := range c.Participants {
+		participants = append(participants, Participant{UserID: p.UserID})
+	}
+	return Conversation{ID: primitive.NewObjectID(), PostID: c.PostID, Participants: participants, Messages: []Message{}, CreatedAt: now, UpdatedAt: now}
+}
+
+func (c Conversation) Participant(userId primitive.ObjectID) (Participant, bool) {
+	index := slices.IndexFunc(c.Participants, func(p Participant) bool { return p.UserID == userId })
+	if index < 0 {
+		return Participant{}, false
+	}
+	return c.Participants[index], true
+}
+
+// Other returns the participant on the other side from userId.
+func (c Conversation) Other(userId primitive.ObjectID) Participant {
+	for _, p := range c.Participants {
+		if p.UserID != userId {
+			return p
+		}
+	}
+	return Participant{}
+}
+
+// ContactShared reports whether both sides agreed to reveal their details.
+func (c Conversation) ContactShared() bool {
+	return !slices.ContainsFunc(c.Participants, func(p Participant) bool { return !p.SharesContact })
+}
+
+func (c Conversation) Unread(userId primitive.ObjectID) int {
+	participant, _ := c.Participant(userId)
+	unread := 0
+	for _, m := range c.Messages {
+		if m.Sender != userId && m.CreatedAt.After(participant.LastReadAt) {
+			unread++
+		}
+	}
+	return unread
+}
+
+func (c Conversation) Summary(userId primitive.ObjectID) ConversationSummary {
+	summary := ConversationSummary{ID: c.ID, PostID: c.PostID, Participants: c.Participants, Unread: c.Unread(userId), UpdatedAt: c.UpdatedAt}
+	if len(c.Messages) > 0 {
+		summary.LastMessage = &c.Messages[len(c.Messages)-1]
+	}
+	return summary
+}
+
+func (c *Conversation) addMessage(message Message) {
+	now := time.Now()
+	c.Messages = append(c.Messages, Message{ID: primitive.NewObjectID(), Sender: message.Sender, Content: message.Content, CreatedAt: now})
+	c.UpdatedAt = now
+	// Sending a message implies having read everything before it.
+	c.markRead(message.Sender, now)
+}
+
+func (c *Conversation) markRead(userId primitive.ObjectID, at time.Time) {
+	for index, p := range c.Participants {
+		if p.UserID == userId {
+			c.Participants[index].LastReadAt = at
+		}
+	}
+}
+
+func (c *Conversation) setContactSharing(userId primitive.ObjectID, share bool) {
+	for index, p := range c.Participants {
+		if p.UserID == userId {
+			c.Participants[index].SharesContact = share
+		}
+	}
+	c.UpdatedAt = time.Now()
+}
+
+func sortConversations(conversations []Conversation) {
+	slices.SortStableFunc(conversations, func(a, b Conversation) int { return b.UpdatedAt.Compare(a.UpdatedAt) })
+}
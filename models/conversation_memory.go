@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryConversationStore struct {
+	mu            sync.RWMutex
+	conversations []Conversation
+}
+
+func (s *memoryConversationStore) index(conversationId primitive.ObjectID) int {
+	for index, c := range s.conversations {
+		if c.ID == conversationId {
+			return index
+		}
+	}
+	return -1
+}
+
+func (s *memoryConversationStore) modify(conversationId primitive.ObjectID, change func(*Conversation)) (Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(conversationId)
+	if index < 0 {
+		return Conversation{}, ErrNotFound
+	}
+	conversation := clone(s.conversations[index])
+	change(&conversation)
+	s.conversations[index] = clone(conversation)
+	return clone(conversation), nil
+}
+
+// FindConversations returns the user's conversations, most recent first.
+func (s *memoryConversationStore) FindConversations(ctx context.Context, userId primitive.ObjectID) ([]Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var conversations []Conversation
+	for _, c := range s.conversations {
+		if _, ok := c.Participant(userId); ok {
+			conversations = append(conversations, clone(c))
+		}
+	}
+	sortConversations(conversations)
+	return conversations, nil
+}
+
+func (s *memoryConversationStore) FindConversation(ctx context.Context, conversationId primitive.ObjectID) (Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(conversationId)
+	if index < 0 {
+		return Conversation{}, ErrNotFound
+	}
+	return clone(s.conversations[index]), nil
+}
+
+func (s *memoryConversationStore) FindPostConversation(ctx context.Context, postId, userId, otherId primitive.ObjectID) (Conversation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.conversations {
+		_, hasUser := c.Participant(userId)
+		_, hasOther := c.Participant(otherId)
+		if c.PostID == postId && hasUser && hasOther {
+			return clone(c), nil
+		}
+	}
+	return Conversation{}, ErrNotFound
+}
+
+func (s *memoryConversationStore) CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newConversation := clone(newConversation(conversation))
+	s.conversations = append(s.conversations, newConversation)
+	return clone(newConversation), nil
+}
+
+func (s *memoryConversationStore) AddMessage(ctx context.Context, conversationId primitive.ObjectID, message Message) (Conversation, error) {
+	return s.modify(conversationId, func(c *Conversation) { c.addMessage(message) })
+}
+
+func (s *memoryConversationStore) MarkConversationRead(ctx context.Context, conversationId, userId primitive.ObjectID) (Conversation, error) {
+	return s.modify(conversationId, func(c *Conversation) { c.markRead(userId, time.Now()) })
+}
+
+func (s *memoryConversationStore) SetContactSharing(ctx context.Context, conversationId, userId primitive.ObjectID, share bool) (Conversation, error) {
+	return s.modify(conversationId, func(c *Conversation) { c.setContactSharing(userId, share) })
+}
+
+func (s *memoryConversationStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
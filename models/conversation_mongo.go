@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoConversationStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoConversationStore) modify(ctx context.Context, conversationId primitive.ObjectID, change func(*Conversation)) (Conversation, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		conversation, err := s.FindConversation(ctx, conversationId)
+		if err != nil {
+			return Conversation{}, err
+		}
+		version := conversation.Version
+		change(&conversation)
+		conversation.Version = version + 1
+		replaced, err := replaceVersion(ctx, s.collection, conversationId, version, conversation)
+		if err != nil {
+			return Conversation{}, err
+		}
+		if replaced {
+			return conversation, nil
+		}
+	}
+	return Conversation{}, ErrConflict
+}
+
+func (s *mongoConversationStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "participants.user_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{Keys: bson.D{{Key: "post_id", Value: 1}}},
+	})
+	return err
+}
+
+func (s *mongoConversationStore) FindConversations(ctx context.Context, userId primitive.ObjectID) ([]Conversation, error) {
+	filter := bson.D{{Key: "participants.user_id", Value: userId}}
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []Conversation{}, err
+	}
+	var conversations []Conversation
+	if err = cursor.All(ctx, &conversations); err != nil {
+		return []Conversation{}, err
+	}
+	return conversations, nil
+}
+
+func (s *mongoConversationStore) FindConversation(ctx context.Context, conversationId primitive.ObjectID) (Conversation, error) {
+	var conversation Conversation
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: conversationId}}).Decode(&conversation)
+	if err != nil {
+		return Conversation{}, notFound(err)
+	}
+	return conversation, nil
+}
+
+func (s *mongoConversationStore) FindPostConversation(ctx context.Context, postId, userId, otherId primitive.ObjectID) (Conversation, error) {
+	filter := bson.D{
+		{Key: "post_id", Value: postId},
+		{Key: "participants.user_id", Value: bson.D{{Key: "$all", Value: bson.A{userId, otherId}}}},
+	}
+	var conversation Conversation
+	err := s.collection.FindOne(ctx, filter).Decode(&conversation)
+	if err != nil {
+		return Conversation{}, notFound(err)
+	}
+	return conversation, nil
+}
+
+func (s *mongoConversationStore) CreateConversation(ctx context.Context, conversation Conversation) (Conversation, error) {
+	newConversation := newConversation(conversation)
+	_, err := s.collection.InsertOne(ctx, newConversation)
+	if err != nil {
+		return Conversation{}, err
+	}
+	return newConversation, nil
+}
+
+func (s *mongoConversationStore) AddMessage(ctx context.Context, conversationId primitive.ObjectID, message Message) (Conversation, error) {
+	return s.modify(ctx, conversationId, func(c *Conversation) { c.addMessage(message) })
+}
+
+func (s *mongoConversationStore) MarkConversationRead(ctx context.Context, conversationId, userId primitive.ObjectID) (Conversation, error) {
+	return s.modify(ctx, conversationId, func(c *Conversation) { c.markRead(userId, time.Now()) })
+}
+
+func (s *mongoConversationStore) SetContactSharing(ctx context.Context, conversationId, userId primitive.ObjectID, share bool) (Conversation, error) {
+	return s.modify(ctx, conversationId, func(c *Conversation) { c.setContactSharing(userId, share) })
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const earthRadius = 6371008.8
+
+const MaxFuzzRadius = 5000
+
+// Point is a GeoJSON point. Coordinates are [longitude, latitude], the order
+// Mongo's 2dsphere index expects.
+type Point struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+// Box is a bounding box for map views. It does not cross the antimeridian.
+type Box struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// Polygon is a GeoJSON polygon with a single outer ring.
+type Polygon struct {
+	Type        string        `bson:"type" json:"type"`
+	Coordinates [][][]float64 `bson:"coordinates" json:"coordinates"`
+}
+
+type NearbyPost struct {
+	Post     `bson:",inline"`
+	Distance float64 `bson:"distance" json:"distance"`
+}
+
+func NewPoint(lng, lat float64) Point {
+	return Point{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+func (p Point) Lng() float64 {
+	return p.Coordinates[0]
+}
+
+func (p Point) Lat() float64 {
+	return p.Coordinates[1]
+}
+
+func (p Point) Validate() error {
+	if p.Type != "Point" {
+		return errors.New("location type must be Point")
+	}
+	if len(p.Coordinates) != 2 {
+		return errors.New("location coordinates must be [longitude, latitude]")
+	}
+	if p.Lng() < -180 || p.Lng() > 180 || p.Lat() < -90 || p.Lat() > 90 {
+		return errors.New("location coordinates are out of range")
+	}
+	return nil
+}
+
+// Distance returns the great-circle distance between a and b in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng() - a.Lng()) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Offset moves the point distance meters along bearing (radians from north).
+func (p Point) Offset(distance, bearing float64) Point {
+	lat1, lng1 := p.Lat()*math.Pi/180, p.Lng()*math.Pi/180
+	angular := distance / earthRadius
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(bearing))
+	lng2 := lng1 + math.Atan2(math.Sin(bearing)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+	lng := math.Mod(lng2*180/math.Pi+540, 360) - 180
+	return NewPoint(lng, lat2*180/math.Pi)
+}
+
+// Fuzz returns a random point within radius meters, uniformly spread over
+// the disc, so a published location does not pinpoint someone's home.
+func (p Point) Fuzz(radius float64) Point {
+	return p.Offset(radius*math.Sqrt(rand.Float64()), rand.Float64()*2*math.Pi)
+}
+
+func (b Box) Validate() error {
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLng < -180 || b.MaxLng > 180 {
+		return errors.New("bounding box is out of range")
+	}
+	if b.MinLat >= b.MaxLat || b.MinLng >= b.MaxLng {
+		return errors.New("bounding box minimums must be below its maximums")
+	}
+	return nil
+}
+
+func (b Box) Contains(p Point) bool {
+	return p.Lng() >= b.MinLng && p.Lng() <= b.MaxLng && p.Lat() >= b.MinLat && p.Lat() <= b.MaxLat
+}
+
+func (b Box) Polygon() Polygon {
+	ring := [][]float64{{b.MinLng, b.MinLat}, {b.MaxLng, b.MinLat}, {b.MaxLng, b.MaxLat}, {b.MinLng, b.MaxLat}, {b.MinLng, b.MinLat}}
+	return Polygon{Type: "Polygon", Coordinates: [][][]float64{ring}}
+}
+
+func (b Box) geometry() bson.D {
+	polygon := b.Polygon()
+	return bson.D{{Key: "type", Value: polygon.Type}, {Key: "coordinates", Value: polygon.Coordinates}}
+}
+
+// BoxAround returns a box covering every point within radius meters of the
+// center, clamped to valid coordinates.
+func BoxAround(center Point, radius float64) Box {
+	dLat := radius / earthRadius * 180 / math.Pi
+	dLng := 180.0
+	if cos := math.Cos(center.Lat() * math.Pi / 180); cos > 0.01 {
+		dLng = math.Min(180, dLat/cos)
+	}
+	return Box{
+		MinLng: math.Max(-180, center.Lng()-dLng),
+		MinLat: math.Max(-90, center.Lat()-dLat),
+		MaxLng: math.Min(180, center.Lng()+dLng),
+		MaxLat: math.Min(90, center.Lat()+dLat),
+	}
+}
+
+func (p Polygon) Validate() error {
+	if p.Type != "Polygon" {
+		return errors.New("area type must be Polygon")
+	}
+	if len(p.Coordinates) != 1 {
+		return errors.New("area must have exactly one ring")
+	}
+	ring := p.Coordinates[0]
+	if len(ring) < 4 || len(ring) > 101 {
+		return errors.New("area ring must have between 4 and 101 positions")
+	}
+	for _, position := range ring {
+		if len(position) != 2 {
+			return errors.New("area positions must be [longitude, latitude]")
+		}
+		if err := NewPoint(position[0], position[1]).Validate(); err != nil {
+			return err
+		}
+	}
+	first, last := ring[0], ring[len(ring)-1]
+	if first[0] != last[0] || first[1] != last[1] {
+		return errors.New("area ring must be closed")
+	}
+	return nil
+}
+
+func (p Polygon) Bounds() Box {
+	box := Box{MinLng: 180, MinLat: 90, MaxLng: -180, MaxLat: -90}
+	for _, position := range p.Coordinates[0] {
+		box.MinLng, box.MaxLng = math.Min(box.MinLng, position[0]), math.Max(box.MaxLng, position[0])
+		box.MinLat, box.MaxLat = math.Min(box.MinLat, position[1]), math.Max(box.MaxLat, position[1])
+	}
+	return box
+}
+
+// Contains uses ray casting on the lng/lat plane, which is accurate enough
+// for neighbourhood-sized areas.
+func (p Polygon) Contains(point Point) bool {
+	ring := p.Coordinates[0]
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > point.Lat()) != (b[1] > point.Lat()) &&
+			point.Lng() < (b[0]-a[0])*(point.Lat()-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
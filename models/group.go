@@ -2,89 +2,172 @@ package models
 
 import (
 	"context"
-	"pet-search-backend-go/db"
+	"slices"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-type member struct {
-	UserID User   `bson:"user_id" json:"user_id"`
-	Role   string `bson:"role" json:"role"`
+const (
+	GroupRoleOwner     = "owner"
+	GroupRoleAdmin     = "admin"
+	GroupRoleModerator = "moderator"
+	GroupRoleMember    = "member"
+)
+
+var groupRoleRanks = map[string]int{
+	GroupRoleMember:    1,
+	GroupRoleModerator: 2,
+	GroupRoleAdmin:     3,
+	GroupRoleOwner:     4,
+}
+
+type Member struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Role     string             `bson:"role" json:"role"`
+	JoinedAt time.Time          `bson:"joined_at" json:"joined_at"`
+}
+
+type JoinRequest struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type Invitation struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	InvitedBy primitive.ObjectID `bson:"invited_by" json:"invited_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 }
 
 type Group struct {
 	ID          primitive.ObjectID `bson:"_id" json:"_id"`
 	GroupName   string             `bson:"group_name" json:"group_name"`
 	Description string             `bson:"description" json:"description"`
-	Members     []member           `bson:"members" json:"members"`
+	Private     bool               `bson:"private" json:"private"`
+	Members     []Member           `bson:"members" json:"members"`
+	Requests    []JoinRequest      `bson:"requests" json:"requests"`
+	Invitations []Invitation       `bson:"invitations" json:"invitations"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	Version     int64              `bson:"version" json:"-"`
+}
+
+// GroupChanges is a partial update of a group; only the fields that are
+// set are changed.
+type GroupChanges struct {
+	GroupName   *string `json:"group_name"`
+	Description *string `json:"description"`
+	Private     *bool   `json:"private"`
 }
 
-var groupsCollection = db.GetClient().Database("petsearch").Collection("groups")
+type GroupStore interface {
+	FindAllGroups(ctx context.Context) ([]Group, error)
+	FindGroup(ctx context.Context, groupId primitive.ObjectID) (Group, error)
+	CreateGroup(ctx context.Context, group Group) (Group, error)
+	UpdateGroup(ctx context.Context, groupId primitive.ObjectID, changes GroupChanges) (Group, error)
+	DeleteGroup(ctx context.Context, groupId primitive.ObjectID) error
+	AddJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error)
+	RemoveJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error)
+	AddInvitation(ctx context.Context, groupId primitive.ObjectID, invitation Invitation) (Group, error)
+	RemoveInvitation(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error)
+	AddMember(ctx context.Context, groupId primitive.ObjectID, member Member) (Group, error)
+	SetMemberRole(ctx context.Context, groupId, userId primitive.ObjectID, role string) (Group, error)
+	RemoveMember(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error)
+}
 
-func updateGroupReturnResult(groupId primitive.ObjectID, filter, update primitive.D) (Group, error) {
-	_, err := groupsCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		return Group{}, err
-	}
-	group, err := FindGroup(groupId)
-	if err != nil {
-		return Group{}, err
+func newGroup(g Group) Group {
+	return Group{ID: primitive.NewObjectID(), GroupName: g.GroupName, Description: g.Description, Private: g.Private, Members: g.Members, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+}
+
+// RoleOf returns the user's role in the group, or "" if they are not a member.
+func (g Group) RoleOf(userId primitive.ObjectID) string {
+	for _, m := range g.Members {
+		if m.UserID == userId {
+			return m.Role
+		}
 	}
-	return group, nil
+	return ""
+}
+
+func (g Group) HasRole(userId primitive.ObjectID, roles ...string) bool {
+	role := g.RoleOf(userId)
+	return role != "" && slices.Contains(roles, role)
+}
+
+func IsGroupRole(role string) bool {
+	_, ok := groupRoleRanks[role]
+	return ok
+}
+
+// GroupRoleRank orders roles from member (1) to owner (4); non-members are 0.
+func GroupRoleRank(role string) int {
+	return groupRoleRanks[role]
+}
+
+func (g Group) HasRequested(userId primitive.ObjectID) bool {
+	return slices.ContainsFunc(g.Requests, func(r JoinRequest) bool { return r.UserID == userId })
 }
 
-func FindAllGroups() ([]Group, error) {
-	cursor, err := groupsCollection.Find(context.Background(), bson.D{})
-	if err != nil {
-		return []Group{}, err
+func (g Group) IsInvited(userId primitive.ObjectID) bool {
+	return slices.ContainsFunc(g.Invitations, func(i Invitation) bool { return i.UserID == userId })
+}
+
+func (g *Group) update(changes GroupChanges) {
+	if changes.GroupName != nil {
+		g.GroupName = *changes.GroupName
 	}
-	var groups []Group
-	if err = cursor.All(context.Background(), &groups); err != nil {
-		return []Group{}, err
+	if changes.Description != nil {
+		g.Description = *changes.Description
 	}
-	return groups, nil
+	if changes.Private != nil {
+		g.Private = *changes.Private
+	}
+	g.UpdatedAt = time.Now()
 }
 
-func FindGroup(groupId primitive.ObjectID) (Group, error) {
-	filter := bson.D{{Key: "_id", Value: groupId}}
-	var result Group
-	err := groupsCollection.FindOne(context.Background(), filter).Decode(&result)
-
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return Group{}, err
-		}
+func (g *Group) addJoinRequest(userId primitive.ObjectID) {
+	if !g.HasRequested(userId) {
+		g.Requests = append(g.Requests, JoinRequest{UserID: userId, CreatedAt: time.Now()})
 	}
-	return result, nil
 }
 
-func (g *Group) Create() (Group, error) {
-	newGroup := Group{ID: primitive.NewObjectID(), GroupName: g.GroupName, Description: g.Description, Members: g.Members, CreatedAt: time.Now(), UpdatedAt: time.Now()}
-	_, err := groupsCollection.InsertOne(context.Background(), newGroup)
-	if err != nil {
-		return Group{}, err
+func (g *Group) removeJoinRequest(userId primitive.ObjectID) {
+	g.Requests = slices.DeleteFunc(g.Requests, func(r JoinRequest) bool { return r.UserID == userId })
+}
+
+func (g *Group) addInvitation(invitation Invitation) {
+	if !g.IsInvited(invitation.UserID) {
+		g.Invitations = append(g.Invitations, Invitation{UserID: invitation.UserID, InvitedBy: invitation.InvitedBy, CreatedAt: time.Now()})
 	}
-	return newGroup, nil
 }
 
-func (g *Group) Update(updatedGroup Group) (Group, error) {
-	filter := bson.D{{Key: "_id", Value: updatedGroup.ID}}
-	update := bson.D{
-		{Key: "$set", Value: Group{ID: g.ID, GroupName: g.GroupName, Description: g.Description, Members: g.Members, CreatedAt: g.CreatedAt, UpdatedAt: time.Now()}},
+func (g *Group) removeInvitation(userId primitive.ObjectID) {
+	g.Invitations = slices.DeleteFunc(g.Invitations, func(i Invitation) bool { return i.UserID == userId })
+}
+
+// addMember admits the user and clears any request or invitation they had.
+func (g *Group) addMember(member Member) {
+	g.removeJoinRequest(member.UserID)
+	g.removeInvitation(member.UserID)
+	if g.RoleOf(member.UserID) == "" {
+		g.Members = append(g.Members, Member{UserID: member.UserID, Role: member.Role, JoinedAt: time.Now()})
 	}
-	return updateGroupReturnResult(g.ID, filter, update)
 }
 
-func (g *Group) Delete() (*mongo.DeleteResult, error) {
-	filter := bson.D{{Key: "_id", Value: g.ID}}
-	result, err := groupsCollection.DeleteOne(context.Background(), filter)
-	if err != nil {
-		return nil, err
+// setMemberRole changes a member's role. Promoting someone to owner hands
+// ownership over, so the previous owner drops to admin.
+func (g *Group) setMemberRole(userId primitive.ObjectID, role string) {
+	for index, m := range g.Members {
+		if role == GroupRoleOwner && m.Role == GroupRoleOwner && m.UserID != userId {
+			g.Members[index].Role = GroupRoleAdmin
+		}
+		if m.UserID == userId {
+			g.Members[index].Role = role
+		}
 	}
-	return result, err
+	g.UpdatedAt = time.Now()
+}
+
+func (g *Group) removeMember(userId primitive.ObjectID) {
+	g.Members = slices.DeleteFunc(g.Members, func(m Member) bool { return m.UserID == userId })
 }
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryGroupStore struct {
+	mu     sync.RWMutex
+	groups []Group
+}
+
+func (s *memoryGroupStore) index(groupId primitive.ObjectID) int {
+	for index, g := range s.groups {
+		if g.ID == groupId {
+			return index
+		}
+	}
+	return -1
+}
+
+func (s *memoryGroupStore) modify(groupId primitive.ObjectID, change func(*Group)) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(groupId)
+	if index < 0 {
+		return Group{}, ErrNotFound
+	}
+	group := clone(s.groups[index])
+	change(&group)
+	s.groups[index] = clone(group)
+	return clone(group), nil
+}
+
+func (s *memoryGroupStore) FindAllGroups(ctx context.Context) ([]Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var groups []Group
+	for _, g := range s.groups {
+		groups = append(groups, clone(g))
+	}
+	return groups, nil
+}
+
+func (s *memoryGroupStore) FindGroup(ctx context.Context, groupId primitive.ObjectID) (Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(groupId)
+	if index < 0 {
+		return Group{}, ErrNotFound
+	}
+	return clone(s.groups[index]), nil
+}
+
+func (s *memoryGroupStore) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newGroup := clone(newGroup(group))
+	s.groups = append(s.groups, newGroup)
+	return clone(newGroup), nil
+}
+
+func (s *memoryGroupStore) UpdateGroup(ctx context.Context, groupId primitive.ObjectID, changes GroupChanges) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.update(changes) })
+}
+
+func (s *memoryGroupStore) DeleteGroup(ctx context.Context, groupId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(groupId)
+	if index < 0 {
+		return ErrNotFound
+	}
+	s.groups = append(s.groups[:index], s.groups[index+1:]...)
+	return nil
+}
+
+func (s *memoryGroupStore) AddJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.addJoinRequest(userId) })
+}
+
+func (s *memoryGroupStore) RemoveJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.removeJoinRequest(userId) })
+}
+
+func (s *memoryGroupStore) AddInvitation(ctx context.Context, groupId primitive.ObjectID, invitation Invitation) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.addInvitation(invitation) })
+}
+
+func (s *memoryGroupStore) RemoveInvitation(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.removeInvitation(userId) })
+}
+
+func (s *memoryGroupStore) AddMember(ctx context.Context, groupId primitive.ObjectID, member Member) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.addMember(member) })
+}
+
+func (s *memoryGroupStore) SetMemberRole(ctx context.Context, groupId, userId primitive.ObjectID, role string) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.setMemberRole(userId, role) })
+}
+
+func (s *memoryGroupStore) RemoveMember(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(groupId, func(g *Group) { g.removeMember(userId) })
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoGroupStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoGroupStore) modify(ctx context.Context, groupId primitive.ObjectID, change func(*Group)) (Group, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		group, err := s.FindGroup(ctx, groupId)
+		if err != nil {
+			return Group{}, err
+		}
+		version := group.Version
+		change(&group)
+		group.Version = version + 1
+		replaced, err := replaceVersion(ctx, s.collection, groupId, version, group)
+		if err != nil {
+			return Group{}, err
+		}
+		if replaced {
+			return group, nil
+		}
+	}
+	return Group{}, ErrConflict
+}
+
+func (s *mongoGroupStore) FindAllGroups(ctx context.Context) ([]Group, error) {
+	cursor, err := s.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return []Group{}, err
+	}
+	var groups []Group
+	if err = cursor.All(ctx, &groups); err != nil {
+		return []Group{}, err
+	}
+	return groups, nil
+}
+
+func (s *mongoGroupStore) FindGroup(ctx context.Context, groupId primitive.ObjectID) (Group, error) {
+	filter := bson.D{{Key: "_id", Value: groupId}}
+	var result Group
+	err := s.collection.FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		return Group{}, notFound(err)
+	}
+	return result, nil
+}
+
+func (s *mongoGroupStore) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	newGroup := newGroup(group)
+	_, err := s.collection.InsertOne(ctx, newGroup)
+	if err != nil {
+		return Group{}, err
+	}
+	return newGroup, nil
+}
+
+func (s *mongoGroupStore) UpdateGroup(ctx context.Context, groupId primitive.ObjectID, changes GroupChanges) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.update(changes) })
+}
+
+func (s *mongoGroupStore) DeleteGroup(ctx context.Context, groupId primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: groupId}}
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoGroupStore) AddJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.addJoinRequest(userId) })
+}
+
+func (s *mongoGroupStore) RemoveJoinRequest(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.removeJoinRequest(userId) })
+}
+
+func (s *mongoGroupStore) AddInvitation(ctx context.Context, groupId primitive.ObjectID, invitation Invitation) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.addInvitation(invitation) })
+}
+
+func (s *mongoGroupStore) RemoveInvitation(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.removeInvitation(userId) })
+}
+
+func (s *mongoGroupStore) AddMember(ctx context.Context, groupId primitive.ObjectID, member Member) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.addMember(member) })
+}
+
+func (s *mongoGroupStore) SetMemberRole(ctx context.Context, groupId, userId primitive.ObjectID, role string) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.setMemberRole(userId, role) })
+}
+
+func (s *mongoGroupStore) RemoveMember(ctx context.Context, groupId, userId primitive.ObjectID) (Group, error) {
+	return s.modify(ctx, groupId, func(g *Group) { g.removeMember(userId) })
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MatchPending   = "pending"
+	MatchConfirmed = "confirmed"
+	MatchDismissed = "dismissed"
+)
+
+const (
+	MaxMatchDistance = 50000
+	minMatchScore    = 0.35
+	matchWindow      = 60 * 24 * time.Hour
+)
+
+// MatchWeights controls how much each signal contributes to a match score.
+// They add up to 1 so scores stay between 0 and 1.
+var MatchWeights = MatchScore{Breed: 0.2, Colors: 0.3, Distance: 0.3, Time: 0.2}
+
+type MatchScore struct {
+	Breed    float64 `bson:"breed" json:"breed"`
+	Colors   float64 `bson:"colors" json:"colors"`
+	Distance float64 `bson:"distance" json:"distance"`
+	Time     float64 `bson:"time" json:"time"`
+}
+
+// MatchFeedback records a confirm or dismiss decision along with the score
+// the match had at the time, so the weights can be tuned later.
+type MatchFeedback struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Decision  string             `bson:"decision" json:"decision"`
+	Score     float64            `bson:"score" json:"score"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type Match struct {
+	ID         primitive.ObjectID `bson:"_id" json:"_id"`
+	LostPost   primitive.ObjectID `bson:"lost_post" json:"lost_post"`
+	FoundPost  primitive.ObjectID `bson:"found_post" json:"found_post"`
+	LostOwner  primitive.ObjectID `bson:"lost_owner" json:"lost_owner"`
+	FoundOwner primitive.ObjectID `bson:"found_owner" json:"found_owner"`
+	Score      float64            `bson:"score" json:"score"`
+	Breakdown  MatchScore         `bson:"breakdown" json:"breakdown"`
+	Distance   float64            `bson:"distance" json:"distance"`
+	Status     string             `bson:"status" json:"status"`
+	Feedback   []MatchFeedback    `bson:"feedback" json:"feedback"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	Version    int64              `bson:"version" json:"-"`
+}
+
+type MatchStore interface {
+	FindMatches(ctx context.Context, postId primitive.ObjectID) ([]Match, error)
+	FindMatch(ctx context.Context, matchId primitive.ObjectID) (Match, error)
+	SaveMatches(ctx context.Context, matches []Match) error
+	AddMatchFeedback(ctx context.Context, matchId primitive.ObjectID, feedback MatchFeedback) (Match, error)
+	DeletePostMatches(ctx context.Context, postId primitive.ObjectID) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+// MatchCandidateKind returns the report kind a post should be matched
+// against, or "" if the post does not take part in matching.
+func MatchCandidateKind(kind string) string {
+	switch kind {
+	case KindLost:
+		return KindFound
+	case KindFound:
+		return KindLost
+	}
+	return ""
+}
+
+// ScoreMatch compares a lost and a found report. Reports for different
+// species, or that score below the threshold, are not a match.
+func ScoreMatch(lost, found Post) (Match, bool) {
+	if lost.Pet == nil || found.Pet == nil || lost.Pet.Species != found.Pet.Species {
+		return Match{}, false
+	}
+	if lost.Location == nil || found.Location == nil {
+		return Match{}, false
+	}
+	distance := Distance(*lost.Location, *found.Location)
+	breakdown := MatchScore{
+		Breed:    breedSimilarity(lost.Pet.Breed, found.Pet.Breed),
+		Colors:   colorSimilarity(lost.Pet.Colors, found.Pet.Colors),
+		Distance: math.Max(0, 1-distance/MaxMatchDistance),
+		Time:     math.Max(0, 1-math.Abs(float64(found.CreatedAt.Sub(lost.CreatedAt)))/float64(matchWindow)),
+	}
+	score := breakdown.Breed*MatchWeights.Breed + breakdown.Colors*MatchWeights.Colors +
+		breakdown.Distance*MatchWeights.Distance + breakdown.Time*MatchWeights.Time
+	if score < minMatchScore {
+		return Match{}, false
+	}
+	return Match{LostPost: lost.ID, FoundPost: found.ID, LostOwner: lost.Creator, FoundOwner: found.Creator, Score: score, Breakdown: breakdown, Distance: distance}, true
+}
+
+// breedSimilarity treats an unknown breed on either side as a half match,
+// since finders often cannot tell.
+func breedSimilarity(a, b string) float64 {
+	switch {
+	case a == "" || b == "":
+		return 0.5
+	case a == b:
+		return 1
+	}
+	return 0
+}
+
+func colorSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0.5
+	}
+	shared := 0
+	for _, color := range a {
+		if slices.Contains(b, color) {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func newMatch(m Match) Match {
+	now := time.Now()
+	m.ID = primitive.NewObjectID()
+	m.Status = MatchPending
+	m.Feedback = []MatchFeedback{}
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	return m
+}
+
+// Involves reports whether the user owns either side of the match.
+func (m Match) Involves(userId primitive.ObjectID) bool {
+	return m.LostOwner == userId || m.FoundOwner == userId
+}
+
+// rescore refreshes the score of an existing match and keeps its feedback.
+func (m *Match) rescore(scored Match) {
+	m.Score = scored.Score
+	m.Breakdown = scored.Breakdown
+	m.Distance = scored.Distance
+	m.UpdatedAt = time.Now()
+}
+
+// addFeedback records a decision. Either side can dismiss a match on its
+// own; it only counts as confirmed once both the owner and finder agree.
+func (m *Match) addFeedback(feedback MatchFeedback) {
+	feedback.Score = m.Score
+	feedback.CreatedAt = time.Now()
+	m.Feedback = append(m.Feedback, feedback)
+	m.UpdatedAt = feedback.CreatedAt
+	if feedback.Decision == MatchDismissed {
+		m.Status = MatchDismissed
+		return
+	}
+	if m.decisionOf(m.LostOwner) == MatchConfirmed && m.decisionOf(m.FoundOwner) == MatchConfirmed {
+		m.Status = MatchConfirmed
+	} else {
+		m.Status = MatchPending
+	}
+}
+
+func (m Match) decisionOf(userId primitive.ObjectID) string {
+	for index := len(m.Feedback) - 1; index >= 0; index-- {
+		if m.Feedback[index].UserID == userId {
+			return m.Feedback[index].Decision
+		}
+	}
+	return ""
+}
+
+func sortMatches(matches []Match) {
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryMatchStore struct {
+	mu      sync.RWMutex
+	matches []Match
+}
+
+func (s *memoryMatchStore) index(match func(Match) bool) int {
+	return slices.IndexFunc(s.matches, match)
+}
+
+func (s *memoryMatchStore) FindMatches(ctx context.Context, postId primitive.ObjectID) ([]Match, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var matches []Match
+	for _, m := range s.matches {
+		if m.LostPost == postId || m.FoundPost == postId {
+			matches = append(matches, clone(m))
+		}
+	}
+	sortMatches(matches)
+	return matches, nil
+}
+
+func (s *memoryMatchStore) FindMatch(ctx context.Context, matchId primitive.ObjectID) (Match, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(func(m Match) bool { return m.ID == matchId })
+	if index < 0 {
+		return Match{}, ErrNotFound
+	}
+	return clone(s.matches[index]), nil
+}
+
+func (s *memoryMatchStore) SaveMatches(ctx context.Context, matches []Match) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, scored := range matches {
+		index := s.index(func(m Match) bool { return m.LostPost == scored.LostPost && m.FoundPost == scored.FoundPost })
+		if index < 0 {
+			s.matches = append(s.matches, clone(newMatch(scored)))
+			continue
+		}
+		match := clone(s.matches[index])
+		match.rescore(scored)
+		s.matches[index] = clone(match)
+	}
+	return nil
+}
+
+func (s *memoryMatchStore) AddMatchFeedback(ctx context.Context, matchId primitive.ObjectID, feedback MatchFeedback) (Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(func(m Match) bool { return m.ID == matchId })
+	if index < 0 {
+		return Match{}, ErrNotFound
+	}
+	match := clone(s.matches[index])
+	match.addFeedback(feedback)
+	s.matches[index] = clone(match)
+	return clone(match), nil
+}
+
+func (s *memoryMatchStore) DeletePostMatches(ctx context.Context, postId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.matches = slices.DeleteFunc(s.matches, func(m Match) bool { return m.LostPost == postId || m.FoundPost == postId })
+	return nil
+}
+
+func (s *memoryMatchStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
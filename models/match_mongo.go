@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoMatchStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoMatchStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "lost_post", Value: 1}, {Key: "found_post", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "found_post", Value: 1}}},
+	})
+	return err
+}
+
+func (s *mongoMatchStore) FindMatches(ctx context.Context, postId primitive.ObjectID) ([]Match, error) {
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "lost_post", Value: postId}},
+		bson.D{{Key: "found_post", Value: postId}},
+	}}}
+	opts := options.Find().SetSort(bson.D{{Key: "score", Value: -1}})
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return []Match{}, err
+	}
+	var matches []Match
+	if err = cursor.All(ctx, &matches); err != nil {
+		return []Match{}, err
+	}
+	return matches, nil
+}
+
+func (s *mongoMatchStore) FindMatch(ctx context.Context, matchId primitive.ObjectID) (Match, error) {
+	var match Match
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: matchId}}).Decode(&match)
+	if err != nil {
+		return Match{}, notFound(err)
+	}
+	return match, nil
+}
+
+// SaveMatches upserts each match on its lost/found pair, refreshing the
+// score but leaving the status and feedback of known matches untouched. The
+// version is bumped so feedback computed from the old score is retried.
+func (s *mongoMatchStore) SaveMatches(ctx context.Context, matches []Match) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	var writes []mongo.WriteModel
+	for _, scored := range matches {
+		match := newMatch(scored)
+		filter := bson.D{{Key: "lost_post", Value: match.LostPost}, {Key: "found_post", Value: match.FoundPost}}
+		update := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "lost_owner", Value: match.LostOwner},
+				{Key: "found_owner", Value: match.FoundOwner},
+				{Key: "score", Value: match.Score},
+				{Key: "breakdown", Value: match.Breakdown},
+				{Key: "distance", Value: match.Distance},
+				{Key: "updated_at", Value: time.Now()},
+			}},
+			{Key: "$setOnInsert", Value: bson.D{
+				{Key: "_id", Value: match.ID},
+				{Key: "status", Value: match.Status},
+				{Key: "feedback", Value: match.Feedback},
+				{Key: "created_at", Value: match.CreatedAt},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+	_, err := s.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (s *mongoMatchStore) AddMatchFeedback(ctx context.Context, matchId primitive.ObjectID, feedback MatchFeedback) (Match, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		match, err := s.FindMatch(ctx, matchId)
+		if err != nil {
+			return Match{}, err
+		}
+		version := match.Version
+		match.addFeedback(feedback)
+		match.Version = version + 1
+		replaced, err := replaceVersion(ctx, s.collection, matchId, version, match)
+		if err != nil {
+			return Match{}, err
+		}
+		if replaced {
+			return match, nil
+		}
+	}
+	return Match{}, ErrConflict
+}
+
+func (s *mongoMatchStore) DeletePostMatches(ctx context.Context, postId primitive.ObjectID) error {
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "lost_post", Value: postId}},
+		bson.D{{Key: "found_post", Value: postId}},
+	}}}
+	_, err := s.collection.DeleteMany(ctx, filter)
+	return err
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotificationPostLiked      = "post_liked"
+	NotificationPostCommented  = "post_commented"
+	NotificationCommentReplied = "comment_replied"
+	NotificationNearbyReport   = "nearby_report"
+)
+
+var NotificationTypes = []string{NotificationPostLiked, NotificationPostCommented, NotificationCommentReplied, NotificationNearbyReport}
+
+const MaxNotificationPage = 100
+
+type Notification struct {
+	ID             primitive.ObjectID `bson:"_id" json:"_id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Type           string             `bson:"type" json:"type"`
+	ActorID        primitive.ObjectID `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	PostID         primitive.ObjectID `bson:"post_id,omitempty" json:"post_id,omitempty"`
+	CommentID      primitive.ObjectID `bson:"comment_id,omitempty" json:"comment_id,omitempty"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id,omitempty" json:"subscription_id,omitempty"`
+	Message        string             `bson:"message" json:"message"`
+	Read           bool               `bson:"read" json:"read"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type NotificationFilter struct {
+	UserID     primitive.ObjectID
+	UnreadOnly bool
+	Limit      int
+}
+
+type NotificationStore interface {
+	FindNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error)
+	CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	CreateNotifications(ctx context.Context, notifications []Notification) ([]Notification, error)
+	MarkRead(ctx context.Context, userId, notificationId primitive.ObjectID) (Notification, error)
+	MarkAllRead(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func IsNotificationType(kind string) bool {
+	return slices.Contains(NotificationTypes, kind)
+}
+
+func newNotification(n Notification) Notification {
+	n.ID = primitive.NewObjectID()
+	n.Read = false
+	n.CreatedAt = time.Now()
+	return n
+}
+
+func (f NotificationFilter) matches(n Notification) bool {
+	return n.UserID == f.UserID && (!f.UnreadOnly || !n.Read)
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryNotificationStore struct {
+	mu            sync.RWMutex
+	notifications []Notification
+}
+
+// FindNotifications returns the newest notifications first; they are kept in
+// insertion order, so that means walking the slice backwards.
+func (s *memoryNotificationStore) FindNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var notifications []Notification
+	for index := len(s.notifications) - 1; index >= 0 && len(notifications) < filter.Limit; index-- {
+		if filter.matches(s.notifications[index]) {
+			notifications = append(notifications, clone(s.notifications[index]))
+		}
+	}
+	return notifications, nil
+}
+
+func (s *memoryNotificationStore) CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var count int64
+	for _, n := range s.notifications {
+		if n.UserID == userId && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryNotificationStore) CreateNotifications(ctx context.Context, notifications []Notification) ([]Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var created []Notification
+	for _, n := range notifications {
+		notification := clone(newNotification(n))
+		s.notifications = append(s.notifications, notification)
+		created = append(created, clone(notification))
+	}
+	return created, nil
+}
+
+func (s *memoryNotificationStore) MarkRead(ctx context.Context, userId, notificationId primitive.ObjectID) (Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, n := range s.notifications {
+		if n.ID == notificationId && n.UserID == userId {
+			s.notifications[index].Read = true
+			return clone(s.notifications[index]), nil
+		}
+	}
+	return Notification{}, ErrNotFound
+}
+
+func (s *memoryNotificationStore) MarkAllRead(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for index, n := range s.notifications {
+		if n.UserID == userId && !n.Read {
+			s.notifications[index].Read = true
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memoryNotificationStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
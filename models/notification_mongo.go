@@ -0,0 +1,81 @@
+package models
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoNotificationStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoNotificationStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}}},
+	})
+	return err
+}
+
+func (s *mongoNotificationStore) FindNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error) {
+	query := bson.D{{Key: "user_id", Value: filter.UserID}}
+	if filter.UnreadOnly {
+		query = append(query, bson.E{Key: "read", Value: false})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(filter.Limit))
+	cursor, err := s.collection.Find(ctx, query, opts)
+	if err != nil {
+		return []Notification{}, err
+	}
+	var notifications []Notification
+	if err = cursor.All(ctx, &notifications); err != nil {
+		return []Notification{}, err
+	}
+	return notifications, nil
+}
+
+func (s *mongoNotificationStore) CountUnread(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	return s.collection.CountDocuments(ctx, bson.D{{Key: "user_id", Value: userId}, {Key: "read", Value: false}})
+}
+
+func (s *mongoNotificationStore) CreateNotifications(ctx context.Context, notifications []Notification) ([]Notification, error) {
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+	created := make([]Notification, len(notifications))
+	documents := make([]interface{}, len(notifications))
+	for index, n := range notifications {
+		created[index] = newNotification(n)
+		documents[index] = created[index]
+	}
+	_, err := s.collection.InsertMany(ctx, documents)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *mongoNotificationStore) MarkRead(ctx context.Context, userId, notificationId primitive.ObjectID) (Notification, error) {
+	filter := bson.D{{Key: "_id", Value: notificationId}, {Key: "user_id", Value: userId}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "read", Value: true}}}}
+	var notification Notification
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&notification)
+	if err != nil {
+		return Notification{}, notFound(err)
+	}
+	return notification, nil
+}
+
+func (s *mongoNotificationStore) MarkAllRead(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	filter := bson.D{{Key: "user_id", Value: userId}, {Key: "read", Value: false}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "read", Value: true}}}}
+	result, err := s.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
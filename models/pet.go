@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	PetSpecies  = []string{"dog", "cat", "bird", "rabbit", "ferret", "small_mammal", "reptile", "horse", "other"}
+	PetColors   = []string{"black", "white", "brown", "chocolate", "tan", "gold", "yellow", "cream", "red", "orange", "gray", "blue", "silver", "fawn", "green", "other"}
+	PetMarkings = []string{"solid", "bicolor", "tricolor", "spotted", "striped", "tabby", "tortoiseshell", "calico", "brindle", "merle", "tuxedo", "point", "patched"}
+	PetSizes    = []string{"tiny", "small", "medium", "large", "giant"}
+	PetSexes    = []string{"male", "female", "unknown"}
+	PetAges     = []string{"baby", "young", "adult", "senior"}
+)
+
+// Pet is the structured description carried by lost, found and sighting
+// reports. Everything except the collar, breed and features comes from a
+// controlled vocabulary so reports can be matched field by field.
+type Pet struct {
+	Species   string   `bson:"species" json:"species"`
+	Breed     string   `bson:"breed,omitempty" json:"breed,omitempty"`
+	Colors    []string `bson:"colors,omitempty" json:"colors,omitempty"`
+	Markings  []string `bson:"markings,omitempty" json:"markings,omitempty"`
+	Size      string   `bson:"size,omitempty" json:"size,omitempty"`
+	Sex       string   `bson:"sex,omitempty" json:"sex,omitempty"`
+	Age       string   `bson:"age,omitempty" json:"age,omitempty"`
+	Collar    string   `bson:"collar,omitempty" json:"collar,omitempty"`
+	Microchip string   `bson:"microchip,omitempty" json:"microchip,omitempty"`
+	Features  []string `bson:"features,omitempty" json:"features,omitempty"`
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func normalizeAll(values []string) []string {
+	var result []string
+	for _, value := range values {
+		value = normalize(value)
+		if value != "" && !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// Normalize lowercases and trims the vocabulary fields and strips separators
+// from the microchip number so stored values compare exactly.
+func (p *Pet) Normalize() {
+	p.Species = normalize(p.Species)
+	p.Breed = normalize(p.Breed)
+	p.Colors = normalizeAll(p.Colors)
+	p.Markings = normalizeAll(p.Markings)
+	p.Size = normalize(p.Size)
+	p.Sex = normalize(p.Sex)
+	p.Age = normalize(p.Age)
+	p.Collar = strings.TrimSpace(p.Collar)
+	p.Microchip = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '.' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, p.Microchip)
+	var features []string
+	for _, feature := range p.Features {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			features = append(features, feature)
+		}
+	}
+	p.Features = features
+}
+
+func checkVocabulary(errs []error, field, value string, vocabulary []string) []error {
+	if value != "" && !slices.Contains(vocabulary, value) {
+		errs = append(errs, fmt.Errorf("%s must be one of %s", field, strings.Join(vocabulary, ", ")))
+	}
+	return errs
+}
+
+// Validate expects a normalized pet.
+func (p Pet) Validate() error {
+	if p.Species == "" {
+		return errors.Join(errors.New("species is required"), p.ValidateVocabulary())
+	}
+	return p.ValidateVocabulary()
+}
+
+// ValidateVocabulary checks the fields that are set without requiring any,
+// which is what search filters need.
+func (p Pet) ValidateVocabulary() error {
+	var errs []error
+	errs = checkVocabulary(errs, "species", p.Species, PetSpecies)
+	for _, color := range p.Colors {
+		errs = checkVocabulary(errs, "colors", color, PetColors)
+	}
+	for _, marking := range p.Markings {
+		errs = checkVocabulary(errs, "markings", marking, PetMarkings)
+	}
+	errs = checkVocabulary(errs, "size", p.Size, PetSizes)
+	errs = checkVocabulary(errs, "sex", p.Sex, PetSexes)
+	errs = checkVocabulary(errs, "age", p.Age, PetAges)
+	if len(p.Breed) > 64 {
+		errs = append(errs, errors.New("breed must be at most 64 characters"))
+	}
+	if len(p.Collar) > 200 {
+		errs = append(errs, errors.New("collar must be at most 200 characters"))
+	}
+	if p.Microchip != "" && (len(p.Microchip) < 9 || len(p.Microchip) > 15 || strings.ContainsFunc(p.Microchip, func(r rune) bool {
+		return !unicode.IsDigit(r) && !unicode.IsUpper(r)
+	})) {
+		errs = append(errs, errors.New("microchip must be 9 to 15 letters or digits"))
+	}
+	if len(p.Features) > 10 {
+		errs = append(errs, errors.New("at most 10 distinguishing features are allowed"))
+	}
+	return errors.Join(errs...)
+}
@@ -2,13 +2,11 @@ package models
 
 import (
 	"context"
-	"pet-search-backend-go/db"
 	"slices"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Reply struct {
@@ -31,238 +29,310 @@ type Comment struct {
 }
 
 type Post struct {
-	ID        primitive.ObjectID   `bson:"_id" json:"_id"`
-	Title     string               `bson:"title" json:"title"`
-	ImageUrl  string               `bson:"imageUrl" json:"imageUrl"`
-	Content   string               `bson:"content" json:"content"`
-	Creator   primitive.ObjectID   `bson:"creator" json:"creator"`
-	Likes     []primitive.ObjectID `bson:"likes" json:"likes"`
-	Comments  []Comment            `bson:"comments" json:"comments"`
-	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID   `bson:"_id" json:"_id"`
+	Title           string               `bson:"title" json:"title"`
+	ImageUrl        string               `bson:"imageUrl" json:"imageUrl"`
+	Content         string               `bson:"content" json:"content"`
+	Kind            string               `bson:"kind" json:"kind"`
+	Status          string               `bson:"status,omitempty" json:"status,omitempty"`
+	StatusChangedAt time.Time            `bson:"status_changed_at,omitempty" json:"status_changed_at,omitempty"`
+	StatusHistory   []StatusChange       `bson:"status_history,omitempty" json:"status_history,omitempty"`
+	Pet             *Pet                 `bson:"pet,omitempty" json:"pet,omitempty"`
+	Location        *Point               `bson:"location,omitempty" json:"location,omitempty"`
+	FuzzRadius      float64              `bson:"fuzz_radius,omitempty" json:"fuzz_radius,omitempty"`
+	Creator         primitive.ObjectID   `bson:"creator" json:"creator"`
+	Groups          []primitive.ObjectID `bson:"groups" json:"groups"`
+	Likes           []primitive.ObjectID `bson:"likes" json:"likes"`
+	Comments        []Comment            `bson:"comments" json:"comments"`
+	Sightings       []Sighting           `bson:"sightings,omitempty" json:"sightings,omitempty"`
+	CreatedAt       time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time            `bson:"updated_at" json:"updated_at"`
+	Version         int64                `bson:"version" json:"-"`
 }
 
-var postsCollection = db.GetClient().Database("petsearch").Collection("posts")
+type PostFilter struct {
+	Group     primitive.ObjectID
+	Kind      string
+	Status    string
+	Species   string
+	Breed     string
+	Colors    []string
+	Markings  []string
+	Size      string
+	Sex       string
+	Age       string
+	Microchip string
+}
+
+type PostStore interface {
+	FindPosts(ctx context.Context, filter PostFilter) ([]Post, error)
+	FindPostsNear(ctx context.Context, center Point, radius float64, filter PostFilter) ([]NearbyPost, error)
+	FindPostsWithin(ctx context.Context, box Box, filter PostFilter) ([]Post, error)
+	FindPost(ctx context.Context, postId primitive.ObjectID) (Post, error)
+	CreatePost(ctx context.Context, post Post) (Post, error)
+	UpdatePost(ctx context.Context, post Post) (Post, error)
+	DeletePost(ctx context.Context, postId primitive.ObjectID) error
+	EnsureIndexes(ctx context.Context) error
+	SetPostStatus(ctx context.Context, postId primitive.ObjectID, status string, userId primitive.ObjectID) (Post, error)
+	LikePost(ctx context.Context, postId, userId primitive.ObjectID) (Post, error)
+	AddComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error)
+	LikeComment(ctx context.Context, postId, commentId, userId primitive.ObjectID) (Post, error)
+	UpdateComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error)
+	DeleteComment(ctx context.Context, postId, commentId primitive.ObjectID) (Post, error)
+	ReplyToComment(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error)
+	EditReply(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error)
+	LikeReply(ctx context.Context, postId, commentId, replyId, userId primitive.ObjectID) (Post, error)
+	DeleteReply(ctx context.Context, postId, commentId, replyId primitive.ObjectID) (Post, error)
+	AddSighting(ctx context.Context, postId primitive.ObjectID, sighting Sighting) (Post, error)
+	DeleteSighting(ctx context.Context, postId, sightingId primitive.ObjectID) (Post, error)
+}
 
-func updateReturnResult(postId primitive.ObjectID, filter, update primitive.D) (Post, error) {
-	_, err := postsCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		return Post{}, err
+func newPost(p Post) Post {
+	now := time.Now()
+	post := Post{ID: primitive.NewObjectID(), Title: p.Title, ImageUrl: p.ImageUrl, Content: p.Content, Kind: p.Kind, Pet: p.Pet, Creator: p.Creator, Groups: p.Groups, CreatedAt: now, UpdatedAt: now}
+	post.setLocation(p.Location, p.FuzzRadius)
+	if post.Kind == "" {
+		post.Kind = KindGeneral
 	}
-	post, err := FindPost(postId)
-	if err != nil {
-		return Post{}, err
+	if IsReport(post.Kind) {
+		post.Status = StatusOpen
+		post.StatusChangedAt = now
+		post.StatusHistory = []StatusChange{{To: StatusOpen, ChangedBy: p.Creator, ChangedAt: now}}
 	}
-	return post, nil
+	return post
 }
 
-func FindAllPosts() ([]Post, error) {
-	cursor, err := postsCollection.Find(context.Background(), bson.D{})
-	if err != nil {
-		return []Post{}, err
+// setLocation stores the point, displaced at random within fuzzRadius meters
+// when the reporter asked for privacy. The exact point is never kept.
+func (p *Post) setLocation(location *Point, fuzzRadius float64) {
+	if location == nil {
+		return
 	}
-	var posts []Post
-	if err = cursor.All(context.Background(), &posts); err != nil {
-		return []Post{}, err
+	point := NewPoint(location.Lng(), location.Lat())
+	if fuzzRadius > 0 {
+		point = point.Fuzz(fuzzRadius)
 	}
-	return posts, nil
+	p.Location = &point
+	p.FuzzRadius = fuzzRadius
 }
 
-func FindPost(postId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: postId}}
-	var result Post
-	err := postsCollection.FindOne(context.Background(), filter).Decode(&result)
+func (f PostFilter) matches(p Post) bool {
+	if !f.Group.IsZero() && !slices.Contains(p.Groups, f.Group) {
+		return false
+	}
+	if f.Kind != "" && p.Kind != f.Kind {
+		return false
+	}
+	if f.Status != "" && p.Status != f.Status {
+		return false
+	}
+	if !f.matchesPet() {
+		return true
+	}
+	if p.Pet == nil {
+		return false
+	}
+	return (f.Species == "" || p.Pet.Species == f.Species) &&
+		(f.Breed == "" || p.Pet.Breed == f.Breed) &&
+		(len(f.Colors) == 0 || slices.ContainsFunc(f.Colors, func(c string) bool { return slices.Contains(p.Pet.Colors, c) })) &&
+		(len(f.Markings) == 0 || slices.ContainsFunc(f.Markings, func(m string) bool { return slices.Contains(p.Pet.Markings, m) })) &&
+		(f.Size == "" || p.Pet.Size == f.Size) &&
+		(f.Sex == "" || p.Pet.Sex == f.Sex) &&
+		(f.Age == "" || p.Pet.Age == f.Age) &&
+		(f.Microchip == "" || p.Pet.Microchip == f.Microchip)
+}
+
+func (f PostFilter) matchesPet() bool {
+	return f.Species != "" || f.Breed != "" || len(f.Colors) > 0 || len(f.Markings) > 0 || f.Size != "" || f.Sex != "" || f.Age != "" || f.Microchip != ""
+}
 
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return Post{}, err
+func (f PostFilter) bson() bson.D {
+	filter := bson.D{}
+	if !f.Group.IsZero() {
+		filter = append(filter, bson.E{Key: "groups", Value: f.Group})
+	}
+	if f.Kind != "" {
+		filter = append(filter, bson.E{Key: "kind", Value: f.Kind})
+	}
+	if f.Status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: f.Status})
+	}
+	fields := []bson.E{{Key: "pet.species", Value: f.Species}, {Key: "pet.breed", Value: f.Breed}, {Key: "pet.size", Value: f.Size}, {Key: "pet.sex", Value: f.Sex}, {Key: "pet.age", Value: f.Age}, {Key: "pet.microchip", Value: f.Microchip}}
+	for _, field := range fields {
+		if field.Value != "" {
+			filter = append(filter, field)
 		}
 	}
-	return result, nil
+	if len(f.Colors) > 0 {
+		filter = append(filter, bson.E{Key: "pet.colors", Value: bson.D{{Key: "$in", Value: f.Colors}}})
+	}
+	if len(f.Markings) > 0 {
+		filter = append(filter, bson.E{Key: "pet.markings", Value: bson.D{{Key: "$in", Value: f.Markings}}})
+	}
+	return filter
 }
 
-func (p *Post) Create() (Post, error) {
-	newPost := Post{ID: primitive.NewObjectID(), Title: p.Title, ImageUrl: p.ImageUrl, Content: p.Content, Creator: p.Creator, CreatedAt: time.Now(), UpdatedAt: time.Now()}
-	_, err := postsCollection.InsertOne(context.Background(), newPost)
-	if err != nil {
-		return Post{}, err
+func (p *Post) update(updatedPost Post) {
+	p.Title = updatedPost.Title
+	p.ImageUrl = updatedPost.ImageUrl
+	p.Content = updatedPost.Content
+	if updatedPost.Pet != nil {
+		p.Pet = updatedPost.Pet
+	}
+	if updatedPost.Location != nil {
+		p.setLocation(updatedPost.Location, updatedPost.FuzzRadius)
 	}
-	return newPost, nil
+	p.UpdatedAt = time.Now()
 }
 
-func (p *Post) Update(updatedPost Post) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: updatedPost.ID}}
-	update := bson.D{
-		{Key: "$set", Value: Post{ID: p.ID, Title: p.Title, ImageUrl: p.ImageUrl, Content: p.Content, Creator: p.Creator, CreatedAt: p.CreatedAt, UpdatedAt: time.Now()}},
+// VisibleTo reports whether the user may see the post. groups holds the
+// post's target groups: posts without groups, or with at least one public
+// group, are visible to everyone; otherwise only members and the author see it.
+func (p Post) VisibleTo(userId primitive.ObjectID, groups []Group) bool {
+	if len(p.Groups) == 0 || p.Creator == userId {
+		return true
 	}
-	return updateReturnResult(p.ID, filter, update)
+	for _, g := range groups {
+		if !g.Private || g.RoleOf(userId) != "" {
+			return true
+		}
+	}
+	return false
 }
 
-func Delete(postId primitive.ObjectID) (*mongo.DeleteResult, error) {
-	filter := bson.D{{Key: "_id", Value: postId}}
-	result, err := postsCollection.DeleteOne(context.Background(), filter)
-	if err != nil {
-		return nil, err
+// Participants returns the author and everyone who commented or replied,
+// the users who follow the post's conversation.
+func (p Post) Participants() []primitive.ObjectID {
+	participants := []primitive.ObjectID{p.Creator}
+	add := func(userId primitive.ObjectID) {
+		if !slices.Contains(participants, userId) {
+			participants = append(participants, userId)
+		}
 	}
-	return result, nil
+	for _, c := range p.Comments {
+		add(c.Creator)
+		for _, r := range c.Replies {
+			add(r.Creator)
+		}
+	}
+	return participants
 }
 
-func (p *Post) Like(userId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
-	var userLikes []primitive.ObjectID
-	if !(slices.Contains(p.Likes, userId)) {
-		userLikes = append(p.Likes, userId)
-	} else {
-		for _, id := range p.Likes {
-			if id != userId {
-				userLikes = append(userLikes, id)
-			}
+func (p Post) FindComment(commentId primitive.ObjectID) (Comment, bool) {
+	for _, c := range p.Comments {
+		if c.ID == commentId {
+			return c, true
+		}
+	}
+	return Comment{}, false
+}
+
+func (p Post) FindReply(commentId, replyId primitive.ObjectID) (Reply, bool) {
+	comment, ok := p.FindComment(commentId)
+	if !ok {
+		return Reply{}, false
+	}
+	for _, r := range comment.Replies {
+		if r.ID == replyId {
+			return r, true
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "likes", Value: userLikes}}},
+	return Reply{}, false
+}
+
+func toggleLike(likes []primitive.ObjectID, userId primitive.ObjectID) []primitive.ObjectID {
+	if !(slices.Contains(likes, userId)) {
+		return append(slices.Clone(likes), userId)
 	}
-	return updateReturnResult(p.ID, filter, update)
+	var userLikes []primitive.ObjectID
+	for _, id := range likes {
+		if id != userId {
+			userLikes = append(userLikes, id)
+		}
+	}
+	return userLikes
 }
 
-func (p *Post) AddComment(comment Comment) (Post, error) {
+func (p *Post) like(userId primitive.ObjectID) {
+	p.Likes = toggleLike(p.Likes, userId)
+}
+
+func (p *Post) addComment(comment Comment) {
 	newComment := Comment{ID: primitive.NewObjectID(), Creator: comment.Creator, Content: comment.Content, Likes: comment.Likes, CreatedAt: time.Now(), UpdatedAt: time.Now()}
-	commentsList := append(p.Comments, newComment)
-	filter := bson.D{{Key: "_id", Value: p.ID}}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: commentsList}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
+	p.Comments = append(p.Comments, newComment)
 }
 
-func (p *Post) LikeComment(commentId, userId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) likeComment(commentId, userId primitive.ObjectID) {
 	for index, c := range p.Comments {
 		if c.ID == commentId {
-			var userCommentLikes []primitive.ObjectID
-			if !(slices.Contains(c.Likes, userId)) {
-				userCommentLikes = append(c.Likes, userId)
-			} else {
-				for _, id := range c.Likes {
-					if id != userId {
-						userCommentLikes = append(userCommentLikes, id)
-					}
-				}
-			}
-			c = Comment{ID: c.ID, Creator: c.Creator, Content: c.Content, Likes: userCommentLikes, CreatedAt: c.CreatedAt, UpdatedAt: c.UpdatedAt}
-			p.Comments[index] = c
+			p.Comments[index].Likes = toggleLike(c.Likes, userId)
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
 }
 
-func (p *Post) UpdateComment(comment Comment) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) updateComment(comment Comment) {
 	for index, c := range p.Comments {
 		if c.ID == comment.ID {
-			c = Comment{ID: comment.ID, Creator: c.Creator, Content: comment.Content, Likes: c.Likes, CreatedAt: c.CreatedAt, UpdatedAt: time.Now()}
-			p.Comments[index] = c
+			p.Comments[index].Content = comment.Content
+			p.Comments[index].UpdatedAt = time.Now()
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
+}
+
+func (p *Post) deleteComment(commentId primitive.ObjectID) {
+	var newCommentsList []Comment
+	for _, c := range p.Comments {
+		if c.ID != commentId {
+			newCommentsList = append(newCommentsList, c)
+		}
 	}
-	return updateReturnResult(p.ID, filter, update)
+	p.Comments = newCommentsList
 }
 
-func (p *Post) ReplyToComment(commentId primitive.ObjectID, reply Reply) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) replyToComment(commentId primitive.ObjectID, reply Reply) {
 	for index, c := range p.Comments {
 		if c.ID == commentId {
-			reply = Reply{ID: primitive.NewObjectID(), Creator: reply.Creator, Content: reply.Content, Likes: reply.Likes, CreatedAt: time.Now(), UpdatedAt: time.Now()}
-			p.Comments[index].Replies = append(p.Comments[index].Replies, reply)
+			newReply := Reply{ID: primitive.NewObjectID(), Creator: reply.Creator, Content: reply.Content, Likes: reply.Likes, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+			p.Comments[index].Replies = append(c.Replies, newReply)
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
 }
 
-func (p *Post) EditReply(commentId primitive.ObjectID, reply Reply) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) editReply(commentId primitive.ObjectID, reply Reply) {
 	for _, c := range p.Comments {
 		if c.ID == commentId {
 			for rIndex, r := range c.Replies {
 				if r.ID == reply.ID {
-					reply = Reply{ID: r.ID, Creator: r.Creator, Content: reply.Content, Likes: reply.Likes, CreatedAt: r.CreatedAt, UpdatedAt: time.Now()}
-					c.Replies[rIndex] = reply
+					c.Replies[rIndex] = Reply{ID: r.ID, Creator: r.Creator, Content: reply.Content, Likes: r.Likes, CreatedAt: r.CreatedAt, UpdatedAt: time.Now()}
 				}
 			}
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
 }
 
-func (p *Post) LikeReply(commentId, userId, replyId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) likeReply(commentId, replyId, userId primitive.ObjectID) {
 	for _, c := range p.Comments {
 		if c.ID == commentId {
 			for rIndex, r := range c.Replies {
 				if r.ID == replyId {
-					var userReplyLikes []primitive.ObjectID
-					if !(slices.Contains(r.Likes, userId)) {
-						userReplyLikes = append(r.Likes, userId)
-					} else {
-						for _, id := range r.Likes {
-							if id != userId {
-								userReplyLikes = append(userReplyLikes, id)
-							}
-						}
-					}
-					r = Reply{ID: r.ID, Creator: r.Creator, Content: r.Content, Likes: userReplyLikes, CreatedAt: r.CreatedAt, UpdatedAt: r.UpdatedAt}
-					c.Replies[rIndex] = r
+					c.Replies[rIndex].Likes = toggleLike(r.Likes, userId)
 				}
 			}
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
 }
 
-func (p *Post) DeleteReply(commentId, replyId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
+func (p *Post) deleteReply(commentId, replyId primitive.ObjectID) {
 	for index, c := range p.Comments {
-		var newRepliesList []Reply
 		if c.ID == commentId {
+			var newRepliesList []Reply
 			for _, r := range c.Replies {
 				if r.ID != replyId {
 					newRepliesList = append(newRepliesList, r)
 				}
 			}
-			c = Comment{ID: c.ID, Creator: c.Creator, Content: c.Content, Likes: c.Likes, Replies: newRepliesList, CreatedAt: c.CreatedAt, UpdatedAt: time.Now()}
-			p.Comments[index] = c
+			p.Comments[index].Replies = newRepliesList
+			p.Comments[index].UpdatedAt = time.Now()
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: p.Comments}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
-}
-
-func (p *Post) DeleteComment(commentId primitive.ObjectID) (Post, error) {
-	filter := bson.D{{Key: "_id", Value: p.ID}}
-	var newCommentsList []Comment
-	for _, c := range p.Comments {
-		if c.ID != commentId {
-			newCommentsList = append(newCommentsList, c)
-		}
-	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "comments", Value: newCommentsList}}},
-	}
-	return updateReturnResult(p.ID, filter, update)
 }
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryPostStore struct {
+	mu    sync.RWMutex
+	posts []Post
+}
+
+func (s *memoryPostStore) index(postId primitive.ObjectID) int {
+	for index, p := range s.posts {
+		if p.ID == postId {
+			return index
+		}
+	}
+	return -1
+}
+
+func (s *memoryPostStore) modify(postId primitive.ObjectID, change func(*Post)) (Post, error) {
+	return s.tryModify(postId, func(p *Post) error {
+		change(p)
+		return nil
+	})
+}
+
+func (s *memoryPostStore) tryModify(postId primitive.ObjectID, change func(*Post) error) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(postId)
+	if index < 0 {
+		return Post{}, ErrNotFound
+	}
+	post := clone(s.posts[index])
+	if err := change(&post); err != nil {
+		return Post{}, err
+	}
+	s.posts[index] = clone(post)
+	return clone(post), nil
+}
+
+func (s *memoryPostStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (s *memoryPostStore) FindPosts(ctx context.Context, filter PostFilter) ([]Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var posts []Post
+	for _, p := range s.posts {
+		if filter.matches(p) {
+			posts = append(posts, clone(p))
+		}
+	}
+	return posts, nil
+}
+
+func (s *memoryPostStore) FindPostsNear(ctx context.Context, center Point, radius float64, filter PostFilter) ([]NearbyPost, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var posts []NearbyPost
+	for _, p := range s.posts {
+		if p.Location == nil || !filter.matches(p) {
+			continue
+		}
+		if distance := Distance(center, *p.Location); distance <= radius {
+			posts = append(posts, NearbyPost{Post: clone(p), Distance: distance})
+		}
+	}
+	sort.SliceStable(posts, func(i, j int) bool { return posts[i].Distance < posts[j].Distance })
+	return posts, nil
+}
+
+func (s *memoryPostStore) FindPostsWithin(ctx context.Context, box Box, filter PostFilter) ([]Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var posts []Post
+	for _, p := range s.posts {
+		if p.Location != nil && box.Contains(*p.Location) && filter.matches(p) {
+			posts = append(posts, clone(p))
+		}
+	}
+	return posts, nil
+}
+
+func (s *memoryPostStore) FindPost(ctx context.Context, postId primitive.ObjectID) (Post, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(postId)
+	if index < 0 {
+		return Post{}, ErrNotFound
+	}
+	return clone(s.posts[index]), nil
+}
+
+func (s *memoryPostStore) CreatePost(ctx context.Context, post Post) (Post, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newPost := clone(newPost(post))
+	s.posts = append(s.posts, newPost)
+	return clone(newPost), nil
+}
+
+func (s *memoryPostStore) UpdatePost(ctx context.Context, updatedPost Post) (Post, error) {
+	return s.modify(updatedPost.ID, func(p *Post) { p.update(updatedPost) })
+}
+
+func (s *memoryPostStore) DeletePost(ctx context.Context, postId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(postId)
+	if index < 0 {
+		return ErrNotFound
+	}
+	s.posts = append(s.posts[:index], s.posts[index+1:]...)
+	return nil
+}
+
+func (s *memoryPostStore) SetPostStatus(ctx context.Context, postId primitive.ObjectID, status string, userId primitive.ObjectID) (Post, error) {
+	return s.tryModify(postId, func(p *Post) error { return p.setStatus(status, userId) })
+}
+
+func (s *memoryPostStore) LikePost(ctx context.Context, postId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.like(userId) })
+}
+
+func (s *memoryPostStore) AddComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.addComment(comment) })
+}
+
+func (s *memoryPostStore) LikeComment(ctx context.Context, postId, commentId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.likeComment(commentId, userId) })
+}
+
+func (s *memoryPostStore) UpdateComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.updateComment(comment) })
+}
+
+func (s *memoryPostStore) DeleteComment(ctx context.Context, postId, commentId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.deleteComment(commentId) })
+}
+
+func (s *memoryPostStore) ReplyToComment(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.replyToComment(commentId, reply) })
+}
+
+func (s *memoryPostStore) EditReply(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.editReply(commentId, reply) })
+}
+
+func (s *memoryPostStore) LikeReply(ctx context.Context, postId, commentId, replyId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.likeReply(commentId, replyId, userId) })
+}
+
+func (s *memoryPostStore) DeleteReply(ctx context.Context, postId, commentId, replyId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.deleteReply(commentId, replyId) })
+}
+
+func (s *memoryPostStore) AddSighting(ctx context.Context, postId primitive.ObjectID, sighting Sighting) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.addSighting(sighting) })
+}
+
+func (s *memoryPostStore) DeleteSighting(ctx context.Context, postId, sightingId primitive.ObjectID) (Post, error) {
+	return s.modify(postId, func(p *Post) { p.deleteSighting(sightingId) })
+}
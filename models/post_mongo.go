@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoPostStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoPostStore) modify(ctx context.Context, postId primitive.ObjectID, change func(*Post)) (Post, error) {
+	return s.tryModify(ctx, postId, func(p *Post) error {
+		change(p)
+		return nil
+	})
+}
+
+// tryModify is modify for changes that can be refused. When change returns
+// an error nothing is written and the error is returned.
+func (s *mongoPostStore) tryModify(ctx context.Context, postId primitive.ObjectID, change func(*Post) error) (Post, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		post, err := s.FindPost(ctx, postId)
+		if err != nil {
+			return Post{}, err
+		}
+		version := post.Version
+		if err := change(&post); err != nil {
+			return Post{}, err
+		}
+		post.Version = version + 1
+		replaced, err := replaceVersion(ctx, s.collection, postId, version, post)
+		if err != nil {
+			return Post{}, err
+		}
+		if replaced {
+			return post, nil
+		}
+	}
+	return Post{}, ErrConflict
+}
+
+func (s *mongoPostStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "status", Value: 1}}},
+		{Keys: bson.D{{Key: "groups", Value: 1}}},
+		{Keys: bson.D{{Key: "pet.species", Value: 1}, {Key: "pet.breed", Value: 1}}},
+		{Keys: bson.D{{Key: "pet.colors", Value: 1}}},
+		{Keys: bson.D{{Key: "pet.microchip", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+	})
+	return err
+}
+
+func (s *mongoPostStore) FindPosts(ctx context.Context, filter PostFilter) ([]Post, error) {
+	cursor, err := s.collection.Find(ctx, filter.bson())
+	if err != nil {
+		return []Post{}, err
+	}
+	var posts []Post
+	if err = cursor.All(ctx, &posts); err != nil {
+		return []Post{}, err
+	}
+	return posts, nil
+}
+
+func (s *mongoPostStore) FindPostsNear(ctx context.Context, center Point, radius float64, filter PostFilter) ([]NearbyPost, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.D{
+			{Key: "near", Value: center},
+			{Key: "distanceField", Value: "distance"},
+			{Key: "maxDistance", Value: radius},
+			{Key: "query", Value: filter.bson()},
+			{Key: "spherical", Value: true},
+		}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return []NearbyPost{}, err
+	}
+	var posts []NearbyPost
+	if err = cursor.All(ctx, &posts); err != nil {
+		return []NearbyPost{}, err
+	}
+	return posts, nil
+}
+
+func (s *mongoPostStore) FindPostsWithin(ctx context.Context, box Box, filter PostFilter) ([]Post, error) {
+	query := append(filter.bson(), bson.E{Key: "location", Value: bson.D{
+		{Key: "$geoWithin", Value: bson.D{{Key: "$geometry", Value: box.geometry()}}},
+	}})
+	cursor, err := s.collection.Find(ctx, query)
+	if err != nil {
+		return []Post{}, err
+	}
+	var posts []Post
+	if err = cursor.All(ctx, &posts); err != nil {
+		return []Post{}, err
+	}
+	return posts, nil
+}
+
+func (s *mongoPostStore) FindPost(ctx context.Context, postId primitive.ObjectID) (Post, error) {
+	filter := bson.D{{Key: "_id", Value: postId}}
+	var result Post
+	err := s.collection.FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		return Post{}, notFound(err)
+	}
+	return result, nil
+}
+
+func (s *mongoPostStore) CreatePost(ctx context.Context, post Post) (Post, error) {
+	newPost := newPost(post)
+	_, err := s.collection.InsertOne(ctx, newPost)
+	if err != nil {
+		return Post{}, err
+	}
+	return newPost, nil
+}
+
+func (s *mongoPostStore) UpdatePost(ctx context.Context, updatedPost Post) (Post, error) {
+	return s.modify(ctx, updatedPost.ID, func(p *Post) { p.update(updatedPost) })
+}
+
+func (s *mongoPostStore) DeletePost(ctx context.Context, postId primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: postId}}
+	result, err := s.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoPostStore) SetPostStatus(ctx context.Context, postId primitive.ObjectID, status string, userId primitive.ObjectID) (Post, error) {
+	return s.tryModify(ctx, postId, func(p *Post) error { return p.setStatus(status, userId) })
+}
+
+func (s *mongoPostStore) LikePost(ctx context.Context, postId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.like(userId) })
+}
+
+func (s *mongoPostStore) AddComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.addComment(comment) })
+}
+
+func (s *mongoPostStore) LikeComment(ctx context.Context, postId, commentId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.likeComment(commentId, userId) })
+}
+
+func (s *mongoPostStore) UpdateComment(ctx context.Context, postId primitive.ObjectID, comment Comment) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.updateComment(comment) })
+}
+
+func (s *mongoPostStore) DeleteComment(ctx context.Context, postId, commentId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.deleteComment(commentId) })
+}
+
+func (s *mongoPostStore) ReplyToComment(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.replyToComment(commentId, reply) })
+}
+
+func (s *mongoPostStore) EditReply(ctx context.Context, postId, commentId primitive.ObjectID, reply Reply) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.editReply(commentId, reply) })
+}
+
+func (s *mongoPostStore) LikeReply(ctx context.Context, postId, commentId, replyId, userId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.likeReply(commentId, replyId, userId) })
+}
+
+func (s *mongoPostStore) DeleteReply(ctx context.Context, postId, commentId, replyId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.deleteReply(commentId, replyId) })
+}
+
+func (s *mongoPostStore) AddSighting(ctx context.Context, postId primitive.ObjectID, sighting Sighting) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.addSighting(sighting) })
+}
+
+func (s *mongoPostStore) DeleteSighting(ctx context.Context, postId, sightingId primitive.ObjectID) (Post, error) {
+	return s.modify(ctx, postId, func(p *Post) { p.deleteSighting(sightingId) })
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	KindLost     = "lost"
+	KindFound    = "found"
+	KindSighting = "sighting"
+	KindGeneral  = "general"
+)
+
+const (
+	StatusOpen     = "open"
+	StatusReunited = "reunited"
+	StatusClosed   = "closed"
+	StatusExpired  = "expired"
+)
+
+var reportKinds = []string{KindLost, KindFound, KindSighting, KindGeneral}
+
+var reportStatuses = []string{StatusOpen, StatusReunited, StatusClosed, StatusExpired}
+
+// statusTransitions lists the legal moves out of each status. Reunited and
+// closed are final; an expired report can be reopened or closed.
+var statusTransitions = map[string][]string{
+	StatusOpen:    {StatusReunited, StatusClosed, StatusExpired},
+	StatusExpired: {StatusOpen, StatusClosed},
+}
+
+type StatusChange struct {
+	From      string             `bson:"from" json:"from"`
+	To        string             `bson:"to" json:"to"`
+	ChangedBy primitive.ObjectID `bson:"changed_by" json:"changed_by"`
+	ChangedAt time.Time          `bson:"changed_at" json:"changed_at"`
+}
+
+func IsReportKind(kind string) bool {
+	return slices.Contains(reportKinds, kind)
+}
+
+func IsReportStatus(status string) bool {
+	return slices.Contains(reportStatuses, status)
+}
+
+// IsReport reports whether the kind carries a status lifecycle.
+func IsReport(kind string) bool {
+	return kind == KindLost || kind == KindFound || kind == KindSighting
+}
+
+// CanTransition reports whether a post of the given kind may move between the
+// two statuses. Only lost and found reports can end in a reunion.
+func CanTransition(kind, from, to string) bool {
+	if !IsReport(kind) {
+		return false
+	}
+	if to == StatusReunited && kind == KindSighting {
+		return false
+	}
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// ErrInvalidTransition is returned when a post cannot move from its current
+// status to the requested one.
+var ErrInvalidTransition = errors.New("status change is not allowed")
+
+// setStatus moves the post to status and records the change. The transition
+// is checked here, against the document being written, so two concurrent
+// changes cannot both leave the same status.
+func (p *Post) setStatus(status string, userId primitive.ObjectID) error {
+	if !CanTransition(p.Kind, p.Status, status) {
+		return ErrInvalidTransition
+	}
+	now := time.Now()
+	p.StatusHistory = append(p.StatusHistory, StatusChange{From: p.Status, To: status, ChangedBy: userId, ChangedAt: now})
+	p.Status = status
+	p.StatusChangedAt = now
+	p.UpdatedAt = now
+	return nil
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSetPostStatusChecksTransition(t *testing.T) {
+	ctx := context.Background()
+	posts := NewMemoryStore().Posts
+	userId := primitive.NewObjectID()
+	post, err := posts.CreatePost(ctx, Post{Title: "Lost cat", Kind: KindLost, Creator: userId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both changes are legal from the status the caller last read, but only
+	// the first may be applied.
+	if _, err := posts.SetPostStatus(ctx, post.ID, StatusClosed, userId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posts.SetPostStatus(ctx, post.ID, StatusReunited, userId); err != ErrInvalidTransition {
+		t.Fatalf("err = %v, want ErrInvalidTransition", err)
+	}
+	post, err = posts.FindPost(ctx, post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Status != StatusClosed || len(post.StatusHistory) != 2 {
+		t.Errorf("status = %s with history %+v, want closed after one change", post.Status, post.StatusHistory)
+	}
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxUsedRefreshHashes bounds how many spent refresh tokens a session
+// remembers for reuse detection.
+const maxUsedRefreshHashes = 20
+
+const (
+	MaxDeviceNameLength = 100
+	maxUserAgentLength  = 512
+	// LastSeenInterval is how stale LastSeenAt may get before a request
+	// refreshes it, so authenticating does not write on every call.
+	LastSeenInterval = time.Minute
+)
+
+// Session is one signed-in device, described by the name the client gave at
+// login and the user agent and address it was last seen from. Its refresh
+// token rotates on every use and the hashes of spent tokens are kept, so a
+// replayed one gives away that two parties hold the session and the whole
+// session can be revoked.
+type Session struct {
+	ID          primitive.ObjectID `bson:"_id" json:"_id"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RefreshHash string             `bson:"refresh_hash" json:"-"`
+	UsedHashes  []string           `bson:"used_hashes" json:"-"`
+	DeviceName  string             `bson:"device_name" json:"device_name"`
+	UserAgent   string             `bson:"user_agent" json:"user_agent"`
+	IP          string             `bson:"ip" json:"ip"`
+	LastSeenAt  time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt   time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt   *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type SessionStore interface {
+	FindSession(ctx context.Context, sessionId primitive.ObjectID) (Session, error)
+	// FindUserSessions returns the user's active sessions, most recently
+	// seen first.
+	FindUserSessions(ctx context.Context, userId primitive.ObjectID) ([]Session, error)
+	CreateSession(ctx context.Context, session Session) (Session, error)
+	// RotateSession swaps oldHash for newHash and extends the session. It
+	// returns ErrNotFound when the session is revoked or oldHash is no longer
+	// current, so two refreshes with the same token cannot both succeed.
+	RotateSession(ctx context.Context, sessionId primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (Session, error)
+	TouchSession(ctx context.Context, sessionId primitive.ObjectID, ip string) error
+	RevokeSession(ctx context.Context, userId, sessionId primitive.ObjectID) error
+	RevokeUserSessions(ctx context.Context, userId primitive.ObjectID) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func newSession(s Session) Session {
+	now := time.Now()
+	return Session{
+		ID:          primitive.NewObjectID(),
+		UserID:      s.UserID,
+		RefreshHash: s.RefreshHash,
+		UsedHashes:  []string{},
+		DeviceName:  truncate(strings.TrimSpace(s.DeviceName), MaxDeviceNameLength),
+		UserAgent:   truncate(s.UserAgent, maxUserAgentLength),
+		IP:          s.IP,
+		LastSeenAt:  now,
+		ExpiresAt:   s.ExpiresAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
+
+func (s Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Stale reports whether LastSeenAt is old enough to be worth updating.
+func (s Session) Stale(now time.Time) bool {
+	return now.Sub(s.LastSeenAt) >= LastSeenInterval
+}
+
+// Reused reports whether hash belongs to a refresh token this session has
+// already rotated away from.
+func (s Session) Reused(hash string) bool {
+	return slices.Contains(s.UsedHashes, hash)
+}
+
+func (s *Session) rotate(newHash string, expiresAt, now time.Time) {
+	s.UsedHashes = append(s.UsedHashes, s.RefreshHash)
+	if len(s.UsedHashes) > maxUsedRefreshHashes {
+		s.UsedHashes = s.UsedHashes[len(s.UsedHashes)-maxUsedRefreshHashes:]
+	}
+	s.RefreshHash = newHash
+	s.ExpiresAt = expiresAt
+	s.UpdatedAt = now
+}
+
+func (s *Session) touch(ip string, now time.Time) {
+	s.IP = ip
+	s.LastSeenAt = now
+}
+
+func (s *Session) revoke(now time.Time) {
+	if s.RevokedAt == nil {
+		s.RevokedAt = &now
+		s.UpdatedAt = now
+	}
+}
+
+func sortSessions(sessions []Session) {
+	slices.SortStableFunc(sessions, func(a, b Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySessionStore struct {
+	mu       sync.RWMutex
+	sessions []Session
+}
+
+func (s *memorySessionStore) index(sessionId primitive.ObjectID) int {
+	for index, session := range s.sessions {
+		if session.ID == sessionId {
+			return index
+		}
+	}
+	return -1
+}
+
+func (s *memorySessionStore) FindSession(ctx context.Context, sessionId primitive.ObjectID) (Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(sessionId)
+	if index < 0 {
+		return Session{}, ErrNotFound
+	}
+	return clone(s.sessions[index]), nil
+}
+
+func (s *memorySessionStore) FindUserSessions(ctx context.Context, userId primitive.ObjectID) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	var sessions []Session
+	for _, session := range s.sessions {
+		if session.UserID == userId && session.Active(now) {
+			sessions = append(sessions, clone(session))
+		}
+	}
+	sortSessions(sessions)
+	return sessions, nil
+}
+
+func (s *memorySessionStore) CreateSession(ctx context.Context, session Session) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	created := clone(newSession(session))
+	s.sessions = append(s.sessions, created)
+	return clone(created), nil
+}
+
+func (s *memorySessionStore) RotateSession(ctx context.Context, sessionId primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	index := s.index(sessionId)
+	if index < 0 || !s.sessions[index].Active(now) || s.sessions[index].RefreshHash != oldHash {
+		return Session{}, ErrNotFound
+	}
+	s.sessions[index].rotate(newHash, expiresAt, now)
+	return clone(s.sessions[index]), nil
+}
+
+func (s *memorySessionStore) TouchSession(ctx context.Context, sessionId primitive.ObjectID, ip string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(sessionId)
+	if index < 0 {
+		return ErrNotFound
+	}
+	s.sessions[index].touch(ip, time.Now())
+	return nil
+}
+
+func (s *memorySessionStore) RevokeSession(ctx context.Context, userId, sessionId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(sessionId)
+	if index < 0 || s.sessions[index].UserID != userId {
+		return ErrNotFound
+	}
+	s.sessions[index].revoke(time.Now())
+	return nil
+}
+
+func (s *memorySessionStore) RevokeUserSessions(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var count int64
+	for index, session := range s.sessions {
+		if session.UserID == userId && session.Active(now) {
+			s.sessions[index].revoke(now)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *memorySessionStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoSessionStore struct {
+	collection *mongo.Collection
+}
+
+// EnsureIndexes lets Mongo drop sessions once they expire; revoked sessions
+// are kept until then so a replayed refresh token is still recognised.
+func (s *mongoSessionStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (s *mongoSessionStore) FindSession(ctx context.Context, sessionId primitive.ObjectID) (Session, error) {
+	var session Session
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: sessionId}}).Decode(&session)
+	if err != nil {
+		return Session{}, notFound(err)
+	}
+	return session, nil
+}
+
+func (s *mongoSessionStore) FindUserSessions(ctx context.Context, userId primitive.ObjectID) ([]Session, error) {
+	filter := bson.D{
+		{Key: "user_id", Value: userId},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: time.Now()}}},
+	}
+	cursor, err := s.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}))
+	if err != nil {
+		return []Session{}, err
+	}
+	var sessions []Session
+	if err = cursor.All(ctx, &sessions); err != nil {
+		return []Session{}, err
+	}
+	return sessions, nil
+}
+
+func (s *mongoSessionStore) CreateSession(ctx context.Context, session Session) (Session, error) {
+	created := newSession(session)
+	_, err := s.collection.InsertOne(ctx, created)
+	if err != nil {
+		return Session{}, err
+	}
+	return created, nil
+}
+
+// RotateSession matches on the current hash so the swap is atomic; the
+// spent hash is pushed with $slice to keep the same bound as rotate.
+func (s *mongoSessionStore) RotateSession(ctx context.Context, sessionId primitive.ObjectID, oldHash, newHash string, expiresAt time.Time) (Session, error) {
+	now := time.Now()
+	filter := bson.D{
+		{Key: "_id", Value: sessionId},
+		{Key: "refresh_hash", Value: oldHash},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "refresh_hash", Value: newHash}, {Key: "expires_at", Value: expiresAt}, {Key: "updated_at", Value: now}}},
+		{Key: "$push", Value: bson.D{{Key: "used_hashes", Value: bson.D{
+			{Key: "$each", Value: []string{oldHash}},
+			{Key: "$slice", Value: -maxUsedRefreshHashes},
+		}}}},
+	}
+	var session Session
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&session)
+	if err != nil {
+		return Session{}, notFound(err)
+	}
+	return session, nil
+}
+
+func (s *mongoSessionStore) TouchSession(ctx context.Context, sessionId primitive.ObjectID, ip string) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "ip", Value: ip}, {Key: "last_seen_at", Value: time.Now()}}}}
+	result, err := s.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: sessionId}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoSessionStore) RevokeSession(ctx context.Context, userId, sessionId primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: sessionId}, {Key: "user_id", Value: userId}}
+	now := time.Now()
+	// Only stamp the first revocation; a second logout is still a success.
+	update := bson.A{bson.D{{Key: "$set", Value: bson.D{
+		{Key: "revoked_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$revoked_at", now}}}},
+		{Key: "updated_at", Value: now},
+	}}}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoSessionStore) RevokeUserSessions(ctx context.Context, userId primitive.ObjectID) (int64, error) {
+	now := time.Now()
+	filter := bson.D{
+		{Key: "user_id", Value: userId},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: now}, {Key: "updated_at", Value: now}}}}
+	result, err := s.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	SightingDirections  = []string{"n", "ne", "e", "se", "s", "sw", "w", "nw", "stationary", "unknown"}
+	SightingConfidences = []string{"low", "medium", "high"}
+)
+
+// Sighting is a report, attached to a lost report, of where and when the pet
+// was seen.
+type Sighting struct {
+	ID         primitive.ObjectID `bson:"_id" json:"_id"`
+	Creator    primitive.ObjectID `bson:"user" json:"user"`
+	Location   Point              `bson:"location" json:"location"`
+	ObservedAt time.Time          `bson:"observed_at" json:"observed_at"`
+	Direction  string             `bson:"direction" json:"direction"`
+	Confidence string             `bson:"confidence" json:"confidence"`
+	ImageUrl   string             `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	Notes      string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type Feature struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Geometry   Point          `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Normalize fills in defaults for the optional fields.
+func (s *Sighting) Normalize() {
+	s.Direction = normalize(s.Direction)
+	s.Confidence = normalize(s.Confidence)
+	if s.Direction == "" {
+		s.Direction = "unknown"
+	}
+	if s.Confidence == "" {
+		s.Confidence = "medium"
+	}
+	if s.Location.Type == "" {
+		s.Location.Type = "Point"
+	}
+}
+
+func (s Sighting) Validate() error {
+	if err := s.Location.Validate(); err != nil {
+		return err
+	}
+	if s.ObservedAt.IsZero() {
+		return errors.New("observed_at is required")
+	}
+	if s.ObservedAt.After(time.Now().Add(5 * time.Minute)) {
+		return errors.New("observed_at cannot be in the future")
+	}
+	if !slices.Contains(SightingDirections, s.Direction) {
+		return errors.New("direction must be a compass point, stationary or unknown")
+	}
+	if !slices.Contains(SightingConfidences, s.Confidence) {
+		return errors.New("confidence must be one of low, medium or high")
+	}
+	return nil
+}
+
+func (p Post) FindSighting(sightingId primitive.ObjectID) (Sighting, bool) {
+	for _, s := range p.Sightings {
+		if s.ID == sightingId {
+			return s, true
+		}
+	}
+	return Sighting{}, false
+}
+
+// SightingTrail returns the post's sightings oldest first as a GeoJSON
+// FeatureCollection.
+func (p Post) SightingTrail() FeatureCollection {
+	sightings := slices.Clone(p.Sightings)
+	slices.SortStableFunc(sightings, func(a, b Sighting) int { return a.ObservedAt.Compare(b.ObservedAt) })
+	features := []Feature{}
+	for _, s := range sightings {
+		features = append(features, Feature{Type: "Feature", ID: s.ID.Hex(), Geometry: s.Location, Properties: map[string]any{
+			"user":        s.Creator,
+			"observed_at": s.ObservedAt,
+			"direction":   s.Direction,
+			"confidence":  s.Confidence,
+			"image_url":   s.ImageUrl,
+			"notes":       s.Notes,
+		}})
+	}
+	return FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+func (p *Post) addSighting(sighting Sighting) {
+	p.Sightings = append(p.Sightings, Sighting{ID: primitive.NewObjectID(), Creator: sighting.Creator, Location: NewPoint(sighting.Location.Lng(), sighting.Location.Lat()), ObservedAt: sighting.ObservedAt, Direction: sighting.Direction, Confidence: sighting.Confidence, ImageUrl: sighting.ImageUrl, Notes: sighting.Notes, CreatedAt: time.Now()})
+}
+
+func (p *Post) deleteSighting(sightingId primitive.ObjectID) {
+	p.Sightings = slices.DeleteFunc(p.Sightings, func(s Sighting) bool { return s.ID == sightingId })
+}
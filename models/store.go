@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNotFound = errors.New("document not found")
+
+// ErrConflict is returned when a document kept changing between being read
+// and written back until modify gave up retrying.
+var ErrConflict = errors.New("document was modified concurrently")
+
+// maxModifyAttempts bounds how often a read-modify-write is retried after
+// losing a race with another writer.
+const maxModifyAttempts = 5
+
+type Store struct {
+	Posts         PostStore
+	Users         UserStore
+	Groups        GroupStore
+	Matches       MatchStore
+	Subscriptions SubscriptionStore
+	Notifications NotificationStore
+	Conversations ConversationStore
+	Sessions      SessionStore
+	AccountTokens AccountTokenStore
+	Audit         AuditStore
+}
+
+func NewMongoStore(database *mongo.Database) Store {
+	return Store{
+		Posts:         &mongoPostStore{collection: database.Collection("posts")},
+		Users:         &mongoUserStore{collection: database.Collection("users")},
+		Groups:        &mongoGroupStore{collection: database.Collection("groups")},
+		Matches:       &mongoMatchStore{collection: database.Collection("matches")},
+		Subscriptions: &mongoSubscriptionStore{collection: database.Collection("subscriptions")},
+		Notifications: &mongoNotificationStore{collection: database.Collection("notifications")},
+		Conversations: &mongoConversationStore{collection: database.Collection("conversations")},
+		Sessions:      &mongoSessionStore{collection: database.Collection("sessions")},
+		AccountTokens: &mongoAccountTokenStore{collection: database.Collection("account_tokens")},
+		Audit:         &mongoAuditStore{collection: database.Collection("audit_events")},
+	}
+}
+
+func NewMemoryStore() Store {
+	return Store{
+		Posts:         &memoryPostStore{},
+		Users:         &memoryUserStore{},
+		Groups:        &memoryGroupStore{},
+		Matches:       &memoryMatchStore{},
+		Subscriptions: &memorySubscriptionStore{},
+		Notifications: &memoryNotificationStore{},
+		Conversations: &memoryConversationStore{},
+		Sessions:      &memorySessionStore{},
+		AccountTokens: &memoryAccountTokenStore{},
+		Audit:         &memoryAuditStore{},
+	}
+}
+
+func (s Store) EnsureIndexes(ctx context.Context) error {
+	for _, ensure := range []func(context.Context) error{
+		s.Users.EnsureIndexes,
+		s.Posts.EnsureIndexes,
+		s.Matches.EnsureIndexes,
+		s.Subscriptions.EnsureIndexes,
+		s.Notifications.EnsureIndexes,
+		s.Conversations.EnsureIndexes,
+		s.Sessions.EnsureIndexes,
+		s.AccountTokens.EnsureIndexes,
+		s.Audit.EnsureIndexes,
+	} {
+		if err := ensure(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clone round-trips a document through BSON so the in-memory stores never
+// share slices with their callers and store values the way Mongo would.
+func clone[T any](document T) T {
+	var result T
+	data, err := bson.Marshal(document)
+	if err != nil {
+		panic(err)
+	}
+	if err := bson.Unmarshal(data, &result); err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// replaceVersion writes document back only if the stored copy is still at
+// version, the one it was read at, so a concurrent write is never silently
+// overwritten. Documents saved before versioning have no version field and
+// count as version 0. It reports whether the document was replaced.
+func replaceVersion(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, version int64, document any) (bool, error) {
+	var current any = version
+	if version == 0 {
+		current = bson.D{{Key: "$in", Value: bson.A{nil, 0}}}
+	}
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "version", Value: current}}
+	result, err := collection.ReplaceOne(ctx, filter, document)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func notFound(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	return err
+}
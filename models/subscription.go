@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxWatchRadius   = 50000
+	MaxSubscriptions = 20
+)
+
+// Subscription is a watch area: a circle (Center and Radius) or a polygon
+// (Area). Bounds is a padded bounding box of either shape, indexed so that
+// new reports only need to be tested against the areas around them.
+type Subscription struct {
+	ID        primitive.ObjectID `bson:"_id" json:"_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name      string             `bson:"name" json:"name"`
+	Center    *Point             `bson:"center,omitempty" json:"center,omitempty"`
+	Radius    float64            `bson:"radius,omitempty" json:"radius,omitempty"`
+	Area      *Polygon           `bson:"area,omitempty" json:"area,omitempty"`
+	Species   []string           `bson:"species" json:"species"`
+	Kinds     []string           `bson:"kinds" json:"kinds"`
+	Bounds    Polygon            `bson:"bounds" json:"-"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	Version   int64              `bson:"version" json:"-"`
+}
+
+type SubscriptionStore interface {
+	FindSubscriptions(ctx context.Context, userId primitive.ObjectID) ([]Subscription, error)
+	FindSubscription(ctx context.Context, subscriptionId primitive.ObjectID) (Subscription, error)
+	CreateSubscription(ctx context.Context, subscription Subscription) (Subscription, error)
+	UpdateSubscription(ctx context.Context, subscription Subscription) (Subscription, error)
+	DeleteSubscription(ctx context.Context, subscriptionId primitive.ObjectID) error
+	FindWatchers(ctx context.Context, post Post) ([]Subscription, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+func (s *Subscription) Normalize() {
+	s.Species = normalizeAll(s.Species)
+	s.Kinds = normalizeAll(s.Kinds)
+	if s.Species == nil {
+		s.Species = []string{}
+	}
+	if s.Kinds == nil {
+		s.Kinds = []string{}
+	}
+	if s.Center != nil && s.Center.Type == "" {
+		s.Center.Type = "Point"
+	}
+	if s.Area != nil && s.Area.Type == "" {
+		s.Area.Type = "Polygon"
+	}
+}
+
+func (s Subscription) Validate() error {
+	switch {
+	case s.Center != nil && s.Area != nil:
+		return errors.New("a watch area is either a center and radius or a polygon, not both")
+	case s.Center != nil:
+		if err := s.Center.Validate(); err != nil {
+			return err
+		}
+		if s.Radius <= 0 || s.Radius > MaxWatchRadius {
+			return errors.New("radius must be between 0 and 50000 meters")
+		}
+	case s.Area != nil:
+		if err := s.Area.Validate(); err != nil {
+			return err
+		}
+		bounds := s.Area.Bounds()
+		if Distance(NewPoint(bounds.MinLng, bounds.MinLat), NewPoint(bounds.MaxLng, bounds.MaxLat)) > 2*MaxWatchRadius {
+			return errors.New("area must fit within 100 km")
+		}
+	default:
+		return errors.New("a watch area needs a center and radius or a polygon")
+	}
+	var errs []error
+	for _, species := range s.Species {
+		errs = checkVocabulary(errs, "species", species, PetSpecies)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, kind := range s.Kinds {
+		if MatchCandidateKind(kind) == "" {
+			return errors.New("kinds may only contain lost and found")
+		}
+	}
+	return nil
+}
+
+// Contains reports whether the point lies inside the watch area itself,
+// not just its bounds.
+func (s Subscription) Contains(point Point) bool {
+	if s.Center != nil {
+		return Distance(*s.Center, point) <= s.Radius
+	}
+	return s.Area != nil && s.Area.Contains(point)
+}
+
+// Watches reports whether the subscriber should hear about the post.
+func (s Subscription) Watches(post Post) bool {
+	if post.Location == nil || MatchCandidateKind(post.Kind) == "" {
+		return false
+	}
+	if len(s.Kinds) > 0 && !slices.Contains(s.Kinds, post.Kind) {
+		return false
+	}
+	if len(s.Species) > 0 && (post.Pet == nil || !slices.Contains(s.Species, post.Pet.Species)) {
+		return false
+	}
+	return s.Contains(*post.Location)
+}
+
+// bounds pads the shape's bounding box so geodesic polygon edges never cut
+// into the area they are meant to cover.
+func (s Subscription) bounds() Polygon {
+	var box Box
+	if s.Center != nil {
+		box = BoxAround(*s.Center, s.Radius)
+	} else {
+		box = s.Area.Bounds()
+	}
+	padLng, padLat := (box.MaxLng-box.MinLng)*0.1, (box.MaxLat-box.MinLat)*0.1
+	return Box{
+		MinLng: max(-180, box.MinLng-padLng),
+		MinLat: max(-90, box.MinLat-padLat),
+		MaxLng: min(180, box.MaxLng+padLng),
+		MaxLat: min(90, box.MaxLat+padLat),
+	}.Polygon()
+}
+
+func newSubscription(s Subscription) Subscription {
+	now := time.Now()
+	subscription := Subscription{ID: primitive.NewObjectID(), UserID: s.UserID, Name: s.Name, Center: s.Center, Radius: s.Radius, Area: s.Area, Species: s.Species, Kinds: s.Kinds, CreatedAt: now, UpdatedAt: now}
+	subscription.Bounds = subscription.bounds()
+	return subscription
+}
+
+func (s *Subscription) update(updated Subscription) {
+	*s = Subscription{ID: s.ID, UserID: s.UserID, Name: updated.Name, Center: updated.Center, Radius: updated.Radius, Area: updated.Area, Species: updated.Species, Kinds: updated.Kinds, CreatedAt: s.CreatedAt, UpdatedAt: time.Now()}
+	s.Bounds = s.bounds()
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySubscriptionStore struct {
+	mu            sync.RWMutex
+	subscriptions []Subscription
+}
+
+func (s *memorySubscriptionStore) index(subscriptionId primitive.ObjectID) int {
+	return slices.IndexFunc(s.subscriptions, func(sub Subscription) bool { return sub.ID == subscriptionId })
+}
+
+func (s *memorySubscriptionStore) FindSubscriptions(ctx context.Context, userId primitive.ObjectID) ([]Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var subscriptions []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.UserID == userId {
+			subscriptions = append(subscriptions, clone(sub))
+		}
+	}
+	return subscriptions, nil
+}
+
+func (s *memorySubscriptionStore) FindSubscription(ctx context.Context, subscriptionId primitive.ObjectID) (Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	index := s.index(subscriptionId)
+	if index < 0 {
+		return Subscription{}, ErrNotFound
+	}
+	return clone(s.subscriptions[index]), nil
+}
+
+func (s *memorySubscriptionStore) CreateSubscription(ctx context.Context, subscription Subscription) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	newSubscription := clone(newSubscription(subscription))
+	s.subscriptions = append(s.subscriptions, newSubscription)
+	return clone(newSubscription), nil
+}
+
+func (s *memorySubscriptionStore) UpdateSubscription(ctx context.Context, updated Subscription) (Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(updated.ID)
+	if index < 0 {
+		return Subscription{}, ErrNotFound
+	}
+	subscription := clone(s.subscriptions[index])
+	subscription.update(updated)
+	s.subscriptions[index] = clone(subscription)
+	return clone(subscription), nil
+}
+
+func (s *memorySubscriptionStore) DeleteSubscription(ctx context.Context, subscriptionId primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.index(subscriptionId)
+	if index < 0 {
+		return ErrNotFound
+	}
+	s.subscriptions = slices.Delete(s.subscriptions, index, index+1)
+	return nil
+}
+
+// FindWatchers checks the cheap bounding box before the exact shape.
+func (s *memorySubscriptionStore) FindWatchers(ctx context.Context, post Post) ([]Subscription, error) {
+	if post.Location == nil {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var watchers []Subscription
+	for _, sub := range s.subscriptions {
+		if sub.Bounds.Bounds().Contains(*post.Location) && sub.Watches(post) {
+			watchers = append(watchers, clone(sub))
+		}
+	}
+	return watchers, nil
+}
+
+func (s *memorySubscriptionStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
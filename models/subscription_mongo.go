@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type mongoSubscriptionStore struct {
+	collection *mongo.Collection
+}
+
+func (s *mongoSubscriptionStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "bounds", Value: "2dsphere"}}},
+	})
+	return err
+}
+
+func (s *mongoSubscriptionStore) FindSubscriptions(ctx context.Context, userId primitive.ObjectID) ([]Subscription, error) {
+	cursor, err := s.collection.Find(ctx, bson.D{{Key: "user_id", Value: userId}})
+	if err != nil {
+		return []Subscription{}, err
+	}
+	var subscriptions []Subscription
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		return []Subscription{}, err
+	}
+	return subscriptions, nil
+}
+
+func (s *mongoSubscriptionStore) FindSubscription(ctx context.Context, subscriptionId primitive.ObjectID) (Subscription, error) {
+	var subscription Subscription
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: subscriptionId}}).Decode(&subscription)
+	if err != nil {
+		return Subscription{}, notFound(err)
+	}
+	return subscription, nil
+}
+
+func (s *mongoSubscriptionStore) CreateSubscription(ctx context.Context, subscription Subscription) (Subscription, error) {
+	newSubscription := newSubscription(subscription)
+	_, err := s.collection.InsertOne(ctx, newSubscription)
+	if err != nil {
+		return Subscription{}, err
+	}
+	return newSubscription, nil
+}
+
+func (s *mongoSubscriptionStore) UpdateSubscription(ctx context.Context, updated Subscription) (Subscription, error) {
+	for attempt := 0; attempt < maxModifyAttempts; attempt++ {
+		subscription, err := s.FindSubscription(ctx, updated.ID)
+		if err != nil {
+			return Subscription{}, err
+		}
+		version := subscription.Version
+		subscription.update(updated)
+		subscription.Version = version + 1
+		replaced, err := replaceVersion(ctx, s.collection, subscription.ID, version, subscription)
+		if err != nil {
+			return Subscription{}, err
+		}
+		if replaced {
+			return subscription, nil
+		}
+	}
+	return Subscription{}, ErrConflict
+}
+
+func (s *mongoSubscriptionStore) DeleteSubscription(ctx context.Context, subscriptionId primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: subscriptionId}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FindWatchers lets the 2dsphere index on bounds narrow thousands of watch
+// areas down to the few around the report, then checks the exact shapes.
+func (s *mongoSubscriptionStore) FindWatchers(ctx context.Context, post Post) ([]Subscription, error) {
+	if post.Location == nil {
+		return nil, nil
+	}
+	filter := bson.D{{Key: "bounds", Value: bson.D{
+		{Key: "$geoIntersects", Value: bson.D{{Key: "$geometry", Value: post.Location}}},
+	}}}
+	cursor, err := s.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []Subscription
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	var watchers []Subscription
+	for _, sub := range candidates {
+		if sub.Watches(post) {
+			watchers = append(watchers, sub)
+		}
+	}
+	return watchers, nil
+}
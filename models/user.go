@@ -2,119 +2,223 @@ package models
 
 import (
 	"context"
-	"pet-search-backend-go/db"
+	"slices"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
 )
 
 type Login struct {
-	Email    string
-	Password string
+	Email      string
+	Password   string
+	DeviceName string `json:"device_name"`
 }
 
 type User struct {
-	ID          primitive.ObjectID `bson:"_id" json:"_id"`
-	Username    string             `bson:"username" json:"username"`
-	Email       string             `bson:"email" json:"email"`
-	PhoneNumber string             `bson:"phone_number" json:"phone_number"`
-	Password    string             `bson:"password" json:"password"`
-	Posts       []Post             `bson:"posts" json:"posts"`
-	MemberOf    []Group            `bson:"member_of" json:"member_of"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	ID                 primitive.ObjectID   `bson:"_id" json:"_id"`
+	Username           string               `bson:"username" json:"username"`
+	Email              string               `bson:"email" json:"email"`
+	PhoneNumber        string               `bson:"phone_number" json:"phone_number"`
+	Password           string               `bson:"password" json:"-"`
+	Role               string               `bson:"role" json:"role"`
+	Posts              []Post               `bson:"posts" json:"posts"`
+	MemberOf           []primitive.ObjectID `bson:"member_of" json:"member_of"`
+	MutedNotifications []string             `bson:"muted_notifications,omitempty" json:"muted_notifications,omitempty"`
+	BlockedUsers       []primitive.ObjectID `bson:"blocked_users,omitempty" json:"blocked_users,omitempty"`
+	EmailVerifiedAt    *time.Time           `bson:"email_verified_at,omitempty" json:"email_verified_at,omitempty"`
+	TwoFactor          TwoFactor            `bson:"two_factor" json:"-"`
+	TwoFactorRequired  bool                 `bson:"two_factor_required" json:"two_factor_required"`
+	CreatedAt          time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt          time.Time            `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
 }
 
-var usersCollection = db.GetClient().Database("petsearch").Collection("users")
+// TwoFactor holds a user's TOTP enrollment. Secret is set as soon as
+// enrollment starts, but codes are only asked for once Enabled is true.
+type TwoFactor struct {
+	Secret      string     `bson:"secret,omitempty"`
+	Enabled     bool       `bson:"enabled"`
+	EnabledAt   *time.Time `bson:"enabled_at,omitempty"`
+	BackupCodes []string   `bson:"backup_codes,omitempty"`
+	// LastStep is the last TOTP period a code was accepted for; a code is
+	// never accepted twice.
+	LastStep int64 `bson:"last_step,omitempty"`
+}
 
-func FindAllUsers() ([]User, error) {
-	cursor, err := usersCollection.Find(context.Background(), bson.D{})
-	if err != nil {
-		return []User{}, err
-	}
-	var users []User
-	if err = cursor.All(context.Background(), &users); err != nil {
-		return []User{}, err
-	}
-	return users, nil
+type UserStore interface {
+	FindAllUsers(ctx context.Context) ([]User, error)
+	FindUserByID(ctx context.Context, userId primitive.ObjectID) (User, error)
+	// FindUserByEmail matches the email case-insensitively.
+	FindUserByEmail(ctx context.Context, email string) (User, error)
+	// AddUser returns a DuplicateError when the email or username, compared
+	// case-insensitively, belongs to another user.
+	AddUser(ctx context.Context, user User) (User, error)
+	AddUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error
+	UpdateUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error
+	DeleteUserPost(ctx context.Context, userId, postId primitive.ObjectID) error
+	AddMembership(ctx context.Context, userId, groupId primitive.ObjectID) error
+	RemoveMembership(ctx context.Context, userId, groupId primitive.ObjectID) error
+	SetMutedNotifications(ctx context.Context, userId primitive.ObjectID, muted []string) error
+	BlockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error
+	UnblockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error
+	// SetPassword hashes password and replaces the user's current one.
+	SetPassword(ctx context.Context, userId primitive.ObjectID, password string) error
+	SetEmailVerified(ctx context.Context, userId primitive.ObjectID) error
+	StartTwoFactor(ctx context.Context, userId primitive.ObjectID, secret string) error
+	EnableTwoFactor(ctx context.Context, userId primitive.ObjectID, backupCodes []string, step int64) error
+	DisableTwoFactor(ctx context.Context, userId primitive.ObjectID) error
+	SetBackupCodes(ctx context.Context, userId primitive.ObjectID, backupCodes []string) error
+	SetTwoFactorRequired(ctx context.Context, userId primitive.ObjectID, required bool) error
+	// UseTOTPStep records step as used, or returns ErrNotFound if it or a
+	// later step already was. UseBackupCode removes the code with hash, or
+	// returns ErrNotFound if there is none. Both are atomic so a code cannot
+	// be spent twice by concurrent requests.
+	UseTOTPStep(ctx context.Context, userId primitive.ObjectID, step int64) error
+	UseBackupCode(ctx context.Context, userId primitive.ObjectID, hash string) error
+	EnsureIndexes(ctx context.Context) error
 }
 
-func FindUser(filter bson.D) (User, error) {
-	var result User
-	err := usersCollection.FindOne(context.Background(), filter).Decode(&result)
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return User{}, nil
+		return "", err
 	}
-	return result, err
+	return string(hashedPassword), nil
 }
 
-func (u *User) AddUser() (User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+func newUser(u User) (User, error) {
+	hashedPassword, err := hashPassword(u.Password)
 	if err != nil {
 		return User{}, err
 	}
-	u.Password = string(hashedPassword)
-	newUser := User{ID: primitive.NewObjectID(), Username: u.Username, Email: u.Email, PhoneNumber: u.PhoneNumber, Password: u.Password, Posts: u.Posts, MemberOf: u.MemberOf, CreatedAt: time.Now()}
-	_, err = usersCollection.InsertOne(context.Background(), newUser)
-	if err != nil {
-		return User{}, err
-	}
-	return newUser, nil
+	now := time.Now()
+	return User{ID: primitive.NewObjectID(), Username: u.Username, Email: u.Email, PhoneNumber: u.PhoneNumber, Password: hashedPassword, Role: RoleUser, Posts: u.Posts, CreatedAt: now, UpdatedAt: now}, nil
 }
 
-func (u *User) AddPost(post Post) (*mongo.UpdateResult, error) {
-	filter := bson.D{{Key: "_id", Value: u.ID}}
-	userPosts := append(u.Posts, post)
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "posts", Value: userPosts}}},
-	}
-	result, err := usersCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
+// CanModerate reports whether the user may change content created by others.
+func (u User) CanModerate() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
 }
 
-func (u *User) UpdateUserPosts(newPost Post) error {
-	filter := bson.D{{Key: "_id", Value: u.ID}}
-	var userPostsList []Post
-	for _, post := range u.Posts {
+// EmailVerified reports whether the user has proven they own their email.
+func (u User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// NeedsTwoFactorSetup reports whether an admin requires 2FA the user has
+// not turned on yet.
+func (u User) NeedsTwoFactorSetup() bool {
+	return u.TwoFactorRequired && !u.TwoFactor.Enabled
+}
+
+func (u User) WantsNotification(kind string) bool {
+	return !slices.Contains(u.MutedNotifications, kind)
+}
+
+func (u User) HasBlocked(userId primitive.ObjectID) bool {
+	return slices.Contains(u.BlockedUsers, userId)
+}
+
+func (u *User) addPost(post Post) {
+	u.Posts = append(u.Posts, post)
+}
+
+func (u *User) updatePost(newPost Post) {
+	for index, post := range u.Posts {
 		if post.ID == newPost.ID {
-			post = newPost
+			u.Posts[index] = newPost
 		}
-		userPostsList = append(userPostsList, post)
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "posts", Value: userPostsList}}},
+}
+
+func (u *User) addMembership(groupId primitive.ObjectID) {
+	if !slices.Contains(u.MemberOf, groupId) {
+		u.MemberOf = append(u.MemberOf, groupId)
 	}
-	_, err := usersCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		return err
+}
+
+func (u *User) removeMembership(groupId primitive.ObjectID) {
+	u.MemberOf = slices.DeleteFunc(u.MemberOf, func(id primitive.ObjectID) bool { return id == groupId })
+}
+
+func (u *User) setMutedNotifications(muted []string) {
+	u.MutedNotifications = muted
+}
+
+func (u *User) blockUser(userId primitive.ObjectID) {
+	if !u.HasBlocked(userId) {
+		u.BlockedUsers = append(u.BlockedUsers, userId)
 	}
-	_, err = FindUser(bson.D{{Key: "_id", Value: u.ID}})
-	if err != nil {
-		return err
+}
+
+func (u *User) unblockUser(userId primitive.ObjectID) {
+	u.BlockedUsers = slices.DeleteFunc(u.BlockedUsers, func(id primitive.ObjectID) bool { return id == userId })
+}
+
+func (u *User) setPassword(hashedPassword string, now time.Time) {
+	u.Password = hashedPassword
+	u.UpdatedAt = now
+}
+
+func (u *User) verifyEmail(now time.Time) {
+	if u.EmailVerifiedAt == nil {
+		u.EmailVerifiedAt = &now
 	}
-	return nil
 }
 
-func (u *User) DeleteUserPost(postId primitive.ObjectID) error {
-	filter := bson.D{{Key: "_id", Value: u.ID}}
+func (u *User) startTwoFactor(secret string) {
+	u.TwoFactor = TwoFactor{Secret: secret}
+}
+
+func (u *User) enableTwoFactor(backupCodes []string, step int64, now time.Time) {
+	u.TwoFactor.Enabled = true
+	u.TwoFactor.EnabledAt = &now
+	u.TwoFactor.BackupCodes = backupCodes
+	u.TwoFactor.LastStep = step
+}
+
+func (u *User) disableTwoFactor() {
+	u.TwoFactor = TwoFactor{}
+}
+
+func (u *User) setBackupCodes(backupCodes []string) {
+	u.TwoFactor.BackupCodes = backupCodes
+}
+
+func (u *User) setTwoFactorRequired(required bool) {
+	u.TwoFactorRequired = required
+}
+
+func (u *User) useTOTPStep(step int64) bool {
+	if step <= u.TwoFactor.LastStep {
+		return false
+	}
+	u.TwoFactor.LastStep = step
+	return true
+}
+
+func (u *User) useBackupCode(hash string) bool {
+	index := slices.Index(u.TwoFactor.BackupCodes, hash)
+	if index < 0 {
+		return false
+	}
+	u.TwoFactor.BackupCodes = slices.Delete(u.TwoFactor.BackupCodes, index, index+1)
+	return true
+}
+
+func (u *User) deletePost(postId primitive.ObjectID) {
 	var newPostsList []Post
 	for _, post := range u.Posts {
 		if post.ID != postId {
 			newPostsList = append(newPostsList, post)
 		}
 	}
-	update := bson.D{
-		{Key: "$set", Value: bson.D{{Key: "posts", Value: newPostsList}}},
-	}
-	_, err := usersCollection.UpdateOne(context.Background(), filter, update)
-	if err != nil {
-		return err
-	}
-	return nil
+	u.Posts = newPostsList
 }
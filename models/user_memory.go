@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserStore struct {
+	mu    sync.RWMutex
+	users []User
+}
+
+func (s *memoryUserStore) find(match func(User) bool) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, u := range s.users {
+		if match(u) {
+			return clone(u), nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+func (s *memoryUserStore) modify(userId primitive.ObjectID, change func(*User)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for index, u := range s.users {
+		if u.ID == userId {
+			user := clone(u)
+			change(&user)
+			s.users[index] = clone(user)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *memoryUserStore) FindAllUsers(ctx context.Context) ([]User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var users []User
+	for _, u := range s.users {
+		users = append(users, clone(u))
+	}
+	return users, nil
+}
+
+func (s *memoryUserStore) FindUserByID(ctx context.Context, userId primitive.ObjectID) (User, error) {
+	return s.find(func(u User) bool { return u.ID == userId })
+}
+
+func (s *memoryUserStore) FindUserByEmail(ctx context.Context, email string) (User, error) {
+	return s.find(func(u User) bool { return strings.EqualFold(u.Email, email) })
+}
+
+func (s *memoryUserStore) AddUser(ctx context.Context, user User) (User, error) {
+	newUser, err := newUser(user)
+	if err != nil {
+		return User{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, newUser.Email) {
+			return User{}, DuplicateError{Field: "email"}
+		}
+		if strings.EqualFold(u.Username, newUser.Username) {
+			return User{}, DuplicateError{Field: "username"}
+		}
+	}
+	s.users = append(s.users, clone(newUser))
+	return clone(newUser), nil
+}
+
+func (s *memoryUserStore) AddUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error {
+	return s.modify(userId, func(u *User) { u.addPost(post) })
+}
+
+func (s *memoryUserStore) UpdateUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error {
+	return s.modify(userId, func(u *User) { u.updatePost(post) })
+}
+
+func (s *memoryUserStore) DeleteUserPost(ctx context.Context, userId, postId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.deletePost(postId) })
+}
+
+func (s *memoryUserStore) AddMembership(ctx context.Context, userId, groupId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.addMembership(groupId) })
+}
+
+func (s *memoryUserStore) RemoveMembership(ctx context.Context, userId, groupId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.removeMembership(groupId) })
+}
+
+func (s *memoryUserStore) SetMutedNotifications(ctx context.Context, userId primitive.ObjectID, muted []string) error {
+	return s.modify(userId, func(u *User) { u.setMutedNotifications(muted) })
+}
+
+func (s *memoryUserStore) BlockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.blockUser(blockedId) })
+}
+
+func (s *memoryUserStore) UnblockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.unblockUser(blockedId) })
+}
+
+func (s *memoryUserStore) SetPassword(ctx context.Context, userId primitive.ObjectID, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.modify(userId, func(u *User) { u.setPassword(hashedPassword, time.Now()) })
+}
+
+func (s *memoryUserStore) SetEmailVerified(ctx context.Context, userId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.verifyEmail(time.Now()) })
+}
+
+func (s *memoryUserStore) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (s *memoryUserStore) StartTwoFactor(ctx context.Context, userId primitive.ObjectID, secret string) error {
+	return s.modify(userId, func(u *User) { u.startTwoFactor(secret) })
+}
+
+func (s *memoryUserStore) EnableTwoFactor(ctx context.Context, userId primitive.ObjectID, backupCodes []string, step int64) error {
+	return s.modify(userId, func(u *User) { u.enableTwoFactor(backupCodes, step, time.Now()) })
+}
+
+func (s *memoryUserStore) DisableTwoFactor(ctx context.Context, userId primitive.ObjectID) error {
+	return s.modify(userId, func(u *User) { u.disableTwoFactor() })
+}
+
+func (s *memoryUserStore) SetBackupCodes(ctx context.Context, userId primitive.ObjectID, backupCodes []string) error {
+	return s.modify(userId, func(u *User) { u.setBackupCodes(backupCodes) })
+}
+
+func (s *memoryUserStore) SetTwoFactorRequired(ctx context.Context, userId primitive.ObjectID, required bool) error {
+	return s.modify(userId, func(u *User) { u.setTwoFactorRequired(required) })
+}
+
+// use applies a single-use change under the lock, so only one caller can
+// succeed, and returns ErrNotFound when change refuses.
+func (s *memoryUserStore) use(userId primitive.ObjectID, change func(*User) bool) error {
+	used := false
+	err := s.modify(userId, func(u *User) { used = change(u) })
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *memoryUserStore) UseTOTPStep(ctx context.Context, userId primitive.ObjectID, step int64) error {
+	return s.use(userId, func(u *User) bool { return u.useTOTPStep(step) })
+}
+
+func (s *memoryUserStore) UseBackupCode(ctx context.Context, userId primitive.ObjectID, hash string) error {
+	return s.use(userId, func(u *User) bool { return u.useBackupCode(hash) })
+}
@@ -0,0 +1,266 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoUserStore struct {
+	collection *mongo.Collection
+}
+
+// caseInsensitive compares strings the way the unique email and username
+// indexes do; queries must use it too for Mongo to pick those indexes.
+var caseInsensitive = &options.Collation{Locale: "en", Strength: 2}
+
+// EnsureIndexes creates the unique email and username indexes. Users saved
+// before they existed may clash once case is ignored, which makes building
+// them fail; the clashing accounts are then listed so they can be merged or
+// renamed before starting again.
+func (s *mongoUserStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("email_unique").SetUnique(true).SetCollation(caseInsensitive)},
+		{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName("username_unique").SetUnique(true).SetCollation(caseInsensitive)},
+	})
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	var clashes []string
+	for _, field := range []string{"email", "username"} {
+		duplicates, findErr := s.findDuplicates(ctx, field)
+		if findErr != nil {
+			return errors.Join(err, findErr)
+		}
+		for _, duplicate := range duplicates {
+			var users []string
+			for _, userId := range duplicate.Users {
+				users = append(users, userId.Hex())
+			}
+			clashes = append(clashes, fmt.Sprintf("%s %q is used by users %s", field, duplicate.Value, strings.Join(users, ", ")))
+		}
+	}
+	return fmt.Errorf("some users share an email or username, ignoring case, so they cannot be made unique; "+
+		"merge or rename these accounts, then start again:\n\t%s", strings.Join(clashes, "\n\t"))
+}
+
+type duplicateValue struct {
+	Value string               `bson:"_id"`
+	Users []primitive.ObjectID `bson:"users"`
+}
+
+// findDuplicates lists the values of field that several users share,
+// ignoring case.
+func (s *mongoUserStore) findDuplicates(ctx context.Context, field string) ([]duplicateValue, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$toLower", Value: "$" + field}}},
+			{Key: "users", Value: bson.D{{Key: "$push", Value: "$_id"}}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$gt", Value: 1}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var duplicates []duplicateValue
+	if err = cursor.All(ctx, &duplicates); err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
+
+// duplicateField names the unique index a failed insert ran into.
+func duplicateField(err error) (string, bool) {
+	if !mongo.IsDuplicateKeyError(err) {
+		return "", false
+	}
+	if strings.Contains(err.Error(), "username_unique") {
+		return "username", true
+	}
+	return "email", true
+}
+
+func (s *mongoUserStore) findOne(ctx context.Context, filter bson.D) (User, error) {
+	var result User
+	err := s.collection.FindOne(ctx, filter).Decode(&result)
+	if err != nil {
+		return User{}, notFound(err)
+	}
+	return result, nil
+}
+
+// update applies field-level operators to one user, so concurrent writes
+// to different fields never undo each other.
+func (s *mongoUserStore) update(ctx context.Context, userId primitive.ObjectID, update bson.D) error {
+	result, err := s.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: userId}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// updateArray is update for array operators. Older documents hold null in
+// posts and member_of, which the array operators refuse, so such a field is
+// first turned into an empty array.
+func (s *mongoUserStore) updateArray(ctx context.Context, userId primitive.ObjectID, field string, update bson.D) error {
+	filter := bson.D{{Key: "_id", Value: userId}, {Key: field, Value: nil}}
+	_, err := s.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: bson.A{}}}}})
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, userId, update)
+}
+
+func (s *mongoUserStore) FindAllUsers(ctx context.Context) ([]User, error) {
+	cursor, err := s.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return []User{}, err
+	}
+	var users []User
+	if err = cursor.All(ctx, &users); err != nil {
+		return []User{}, err
+	}
+	return users, nil
+}
+
+func (s *mongoUserStore) FindUserByID(ctx context.Context, userId primitive.ObjectID) (User, error) {
+	return s.findOne(ctx, bson.D{{Key: "_id", Value: userId}})
+}
+
+func (s *mongoUserStore) FindUserByEmail(ctx context.Context, email string) (User, error) {
+	var result User
+	err := s.collection.FindOne(ctx, bson.D{{Key: "email", Value: email}}, options.FindOne().SetCollation(caseInsensitive)).Decode(&result)
+	if err != nil {
+		return User{}, notFound(err)
+	}
+	return result, nil
+}
+
+func (s *mongoUserStore) AddUser(ctx context.Context, user User) (User, error) {
+	newUser, err := newUser(user)
+	if err != nil {
+		return User{}, err
+	}
+	_, err = s.collection.InsertOne(ctx, newUser)
+	if field, ok := duplicateField(err); ok {
+		return User{}, DuplicateError{Field: field}
+	}
+	if err != nil {
+		return User{}, err
+	}
+	return newUser, nil
+}
+
+func (s *mongoUserStore) AddUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error {
+	return s.updateArray(ctx, userId, "posts", bson.D{{Key: "$push", Value: bson.D{{Key: "posts", Value: post}}}})
+}
+
+func (s *mongoUserStore) UpdateUserPost(ctx context.Context, userId primitive.ObjectID, post Post) error {
+	filter := bson.D{{Key: "_id", Value: userId}, {Key: "posts._id", Value: post.ID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "posts.$", Value: post}}}}
+	_, err := s.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (s *mongoUserStore) DeleteUserPost(ctx context.Context, userId, postId primitive.ObjectID) error {
+	return s.updateArray(ctx, userId, "posts", bson.D{{Key: "$pull", Value: bson.D{{Key: "posts", Value: bson.D{{Key: "_id", Value: postId}}}}}})
+}
+
+func (s *mongoUserStore) AddMembership(ctx context.Context, userId, groupId primitive.ObjectID) error {
+	return s.updateArray(ctx, userId, "member_of", bson.D{{Key: "$addToSet", Value: bson.D{{Key: "member_of", Value: groupId}}}})
+}
+
+func (s *mongoUserStore) RemoveMembership(ctx context.Context, userId, groupId primitive.ObjectID) error {
+	return s.updateArray(ctx, userId, "member_of", bson.D{{Key: "$pull", Value: bson.D{{Key: "member_of", Value: groupId}}}})
+}
+
+func (s *mongoUserStore) SetMutedNotifications(ctx context.Context, userId primitive.ObjectID, muted []string) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{{Key: "muted_notifications", Value: muted}}}})
+}
+
+func (s *mongoUserStore) BlockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error {
+	return s.updateArray(ctx, userId, "blocked_users", bson.D{{Key: "$addToSet", Value: bson.D{{Key: "blocked_users", Value: blockedId}}}})
+}
+
+func (s *mongoUserStore) UnblockUser(ctx context.Context, userId, blockedId primitive.ObjectID) error {
+	return s.updateArray(ctx, userId, "blocked_users", bson.D{{Key: "$pull", Value: bson.D{{Key: "blocked_users", Value: blockedId}}}})
+}
+
+func (s *mongoUserStore) SetPassword(ctx context.Context, userId primitive.ObjectID, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "password", Value: hashedPassword},
+		{Key: "updated_at", Value: time.Now()},
+	}}})
+}
+
+func (s *mongoUserStore) SetEmailVerified(ctx context.Context, userId primitive.ObjectID) error {
+	// $min keeps the time of the first verification.
+	return s.update(ctx, userId, bson.D{{Key: "$min", Value: bson.D{{Key: "email_verified_at", Value: time.Now()}}}})
+}
+
+func (s *mongoUserStore) StartTwoFactor(ctx context.Context, userId primitive.ObjectID, secret string) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{{Key: "two_factor", Value: TwoFactor{Secret: secret}}}}})
+}
+
+func (s *mongoUserStore) EnableTwoFactor(ctx context.Context, userId primitive.ObjectID, backupCodes []string, step int64) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "two_factor.enabled", Value: true},
+		{Key: "two_factor.enabled_at", Value: time.Now()},
+		{Key: "two_factor.backup_codes", Value: backupCodes},
+		{Key: "two_factor.last_step", Value: step},
+	}}})
+}
+
+func (s *mongoUserStore) DisableTwoFactor(ctx context.Context, userId primitive.ObjectID) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{{Key: "two_factor", Value: TwoFactor{}}}}})
+}
+
+func (s *mongoUserStore) SetBackupCodes(ctx context.Context, userId primitive.ObjectID, backupCodes []string) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{{Key: "two_factor.backup_codes", Value: backupCodes}}}})
+}
+
+func (s *mongoUserStore) SetTwoFactorRequired(ctx context.Context, userId primitive.ObjectID, required bool) error {
+	return s.update(ctx, userId, bson.D{{Key: "$set", Value: bson.D{{Key: "two_factor_required", Value: required}}}})
+}
+
+// use runs a conditional update, so the check and the write happen in one
+// step; no match means the code was already spent.
+func (s *mongoUserStore) use(ctx context.Context, filter, update bson.D) error {
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoUserStore) UseTOTPStep(ctx context.Context, userId primitive.ObjectID, step int64) error {
+	filter := bson.D{{Key: "_id", Value: userId}, {Key: "two_factor.last_step", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gte", Value: step}}}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "two_factor.last_step", Value: step}}}}
+	return s.use(ctx, filter, update)
+}
+
+func (s *mongoUserStore) UseBackupCode(ctx context.Context, userId primitive.ObjectID, hash string) error {
+	filter := bson.D{{Key: "_id", Value: userId}, {Key: "two_factor.backup_codes", Value: hash}}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "two_factor.backup_codes", Value: hash}}}}
+	return s.use(ctx, filter, update)
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultAuditPage = 50
+
+// getAuditEvents lists security events, newest first, for site admins.
+func (h *handler) getAuditEvents(context *gin.Context) {
+	if !h.authorizeAdmin(context) {
+		return
+	}
+	filter := models.AuditFilter{Type: context.Query("type"), Limit: defaultAuditPage}
+	if value := context.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > models.MaxAuditPage {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Limit must be between 1 and 200"})
+			return
+		}
+		filter.Limit = parsed
+	}
+	if value := context.Query("user_id"); value != "" {
+		userId, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+			return
+		}
+		filter.UserID = userId
+	}
+	events, err := h.store.Audit.FindAuditEvents(context, filter)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch audit events"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"events": events})
+}
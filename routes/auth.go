@@ -1,25 +1,29 @@
 package routes
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func createToken(userId primitive.ObjectID) (string, error) {
+func (h *handler) createToken(session models.Session) (string, error) {
 	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": userId,
-		"iss": "pet-search",
-		"exp": time.Now().Add(time.Hour).Unix(),
+		"sub": session.UserID,
+		"sid": session.ID.Hex(),
+		"iss": h.cfg.JWT.Issuer,
+		"exp": time.Now().Add(h.cfg.JWT.AccessTokenTTL).Unix(),
 		"iat": time.Now().Unix(),
 	})
-	signingKey := []byte("pet-search-api-secret")
+	signingKey := []byte(h.cfg.JWT.Secret)
 	tokenString, err := claims.SignedString(signingKey)
 	if err != nil {
 		return "", err
@@ -27,51 +31,268 @@ func createToken(userId primitive.ObjectID) (string, error) {
 	return tokenString, nil
 }
 
+// tokens is what login and refresh hand back: a short-lived access token and
+// the single-use refresh token that renews it. Token keeps its old name so
+// existing clients keep working.
+type tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// refreshToken joins the session id to the secret so a refresh can find its
+// session without the secret ever being stored.
+func refreshToken(sessionId primitive.ObjectID, secret string) string {
+	return sessionId.Hex() + "." + secret
+}
+
+func parseRefreshToken(token string) (primitive.ObjectID, string, bool) {
+	id, secret, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return primitive.NilObjectID, "", false
+	}
+	sessionId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, "", false
+	}
+	return sessionId, secret, true
+}
+
+func (h *handler) issueTokens(session models.Session, secret string) (tokens, error) {
+	token, err := h.createToken(session)
+	if err != nil {
+		return tokens{}, err
+	}
+	return tokens{Token: token, RefreshToken: refreshToken(session.ID, secret), ExpiresIn: int64(h.cfg.JWT.AccessTokenTTL.Seconds())}, nil
+}
+
+// startSession opens a new session for the user on the device making the
+// request and returns its first pair of tokens.
+func (h *handler) startSession(context *gin.Context, userId primitive.ObjectID, deviceName string) (tokens, error) {
+	secret, hash, err := models.NewToken()
+	if err != nil {
+		return tokens{}, err
+	}
+	session, err := h.store.Sessions.CreateSession(context, models.Session{
+		UserID:      userId,
+		RefreshHash: hash,
+		DeviceName:  deviceName,
+		UserAgent:   context.Request.UserAgent(),
+		IP:          context.ClientIP(),
+		ExpiresAt:   time.Now().Add(h.cfg.JWT.RefreshTokenTTL),
+	})
+	if err != nil {
+		return tokens{}, err
+	}
+	return h.issueTokens(session, secret)
+}
+
+func currentSessionId(context *gin.Context) (primitive.ObjectID, bool) {
+	sessionId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("sessionId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read session id header"})
+		return primitive.NilObjectID, false
+	}
+	return sessionId, true
+}
+
 func verifyPassword(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
 
-func signup(context *gin.Context) {
-	var newUser models.User
-	err := context.ShouldBindJSON(&newUser)
+// signupRequest is bound separately from models.User, whose password field
+// never appears in JSON.
+type signupRequest struct {
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	PhoneNumber string `json:"phone_number"`
+	Password    string `json:"password"`
+}
+
+func (h *handler) signup(context *gin.Context) {
+	var request signupRequest
+	err := context.ShouldBindJSON(&request)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	createdUser, err := newUser.AddUser()
+	newUser := models.User{Username: request.Username, Email: request.Email, PhoneNumber: request.PhoneNumber, Password: request.Password}
+	newUser.Normalize()
+	if err := errors.Join(newUser.Validate(), models.ValidatePassword(request.Password, newUser)); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid account details", "error": err.Error()})
+		return
+	}
+	createdUser, err := h.store.Users.AddUser(context, newUser)
+	var duplicate models.DuplicateError
+	if errors.As(err, &duplicate) {
+		context.JSON(http.StatusConflict, gin.H{"message": "An account with that " + duplicate.Field + " already exists", "field": duplicate.Field})
+		return
+	}
 	if err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not create post"})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create user"})
 		return
 	}
-	context.JSON(http.StatusCreated, gin.H{"message": "User created", "user": createdUser})
+	if err := h.sendVerification(context, createdUser); err != nil {
+		log.Printf("could not send verification email to user %s: %v", createdUser.ID.Hex(), err)
+	}
+	context.JSON(http.StatusCreated, gin.H{"message": "User created, check your email to verify it", "user": views.Self(createdUser)})
 }
 
-func login(context *gin.Context) {
+func (h *handler) login(context *gin.Context) {
 	var credentials models.Login
 	err := context.ShouldBindJSON(&credentials)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	filter := bson.D{{Key: "email", Value: credentials.Email}}
-	user, err := models.FindUser(filter)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+	email := models.NormalizeEmail(credentials.Email)
+	attempt, ok := h.allowLogin(context, email)
+	if !ok {
 		return
 	}
-	if credentials.Email != user.Email {
+	user, err := h.store.Users.FindUserByEmail(context, email)
+	if err == models.ErrNotFound {
+		h.loginFailed(context, attempt, nil)
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid Username or Password", "error": "email"})
 		return
 	}
+	if err != nil {
+		h.refundLogin(context, attempt)
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not find user"})
+		return
+	}
 	if !(verifyPassword(user.Password, credentials.Password)) {
+		h.loginFailed(context, attempt, &user)
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid Username or Password", "error": "pass"})
 		return
 	}
-	token, err := createToken(user.ID)
+	// Earlier failures are only forgotten once the second step passes too,
+	// or a known password would let the code be guessed without limit.
+	if user.TwoFactor.Enabled || user.TwoFactorRequired {
+		h.refundLogin(context, attempt)
+		h.challengeLoginStep(context, user, credentials.DeviceName)
+		return
+	}
+	h.attemptSucceeded(context, attempt)
+	h.loginSucceeded(context, user, credentials.DeviceName, nil)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// refresh trades a refresh token for a new pair. Each refresh token works
+// once; presenting one that was already rotated away means someone else has
+// a copy, so the session is revoked for whoever holds it.
+func (h *handler) refresh(context *gin.Context) {
+	var request refreshRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	sessionId, secret, ok := parseRefreshToken(request.RefreshToken)
+	if !ok {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		return
+	}
+	session, err := h.store.Sessions.FindSession(context, sessionId)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not refresh session"})
+		return
+	}
+	hash := models.HashToken(secret)
+	if session.Reused(hash) {
+		h.revokeReusedSession(context, session)
+		return
+	}
+	if hash != session.RefreshHash || !session.Active(time.Now()) {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		return
+	}
+	// A session from before 2FA became required must not outlive it; the
+	// user sets it up at their next login.
+	user, err := h.store.Users.FindUserByID(context, session.UserID)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid refresh token"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not refresh session"})
+		return
+	}
+	if user.NeedsTwoFactorSetup() {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Two-factor authentication is required, please log in again to set it up", "error": "two_factor_setup"})
+		return
+	}
+	newSecret, newHash, err := models.NewToken()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not refresh session"})
+		return
+	}
+	rotated, err := h.store.Sessions.RotateSession(context, sessionId, hash, newHash, time.Now().Add(h.cfg.JWT.RefreshTokenTTL))
+	if err == models.ErrNotFound {
+		// Another request rotated this token first, which is reuse as well.
+		h.revokeReusedSession(context, session)
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not refresh session"})
+		return
+	}
+	if err := h.store.Sessions.TouchSession(context, rotated.ID, context.ClientIP()); err != nil {
+		log.Printf("could not update last seen of session %s: %v", rotated.ID.Hex(), err)
+	}
+	issued, err := h.issueTokens(rotated, newSecret)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not refresh session"})
+		return
+	}
+	context.JSON(http.StatusOK, issued)
+}
+
+func (h *handler) revokeReusedSession(context *gin.Context, session models.Session) {
+	log.Printf("refresh token reused for session %s of user %s, revoking it", session.ID.Hex(), session.UserID.Hex())
+	if err := h.store.Sessions.RevokeSession(context, session.UserID, session.ID); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not revoke session"})
+		return
+	}
+	context.JSON(http.StatusUnauthorized, gin.H{"message": "Refresh token was already used, the session has been revoked"})
+}
+
+func (h *handler) logout(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sessionId, ok := currentSessionId(context)
+	if !ok {
+		return
+	}
+	err := h.store.Sessions.RevokeSession(context, userId, sessionId)
+	if err != nil && err != models.ErrNotFound {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log out"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// logoutAll ends every session of the caller, including the current one,
+// for when a device is lost or stolen.
+func (h *handler) logoutAll(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	count, err := h.store.Sessions.RevokeUserSessions(context, userId)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log in user", "error": err})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log out"})
 		return
 	}
-	context.JSON(http.StatusAccepted, gin.H{"message": "Login Successful", "token": token, "user": user.Email})
+	context.JSON(http.StatusOK, gin.H{"message": "Logged out of all sessions", "revoked": count})
 }
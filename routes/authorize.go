@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// authorizeOwner lets the request through when the caller created the content
+// or holds a moderating role. Otherwise it responds with 403 and returns false.
+func (h *handler) authorizeOwner(context *gin.Context, ownerId primitive.ObjectID) bool {
+	userId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
+		return false
+	}
+	if userId == ownerId {
+		return true
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err == nil && user.CanModerate() {
+		return true
+	}
+	context.JSON(http.StatusForbidden, gin.H{"message": "You are not allowed to change this content"})
+	return false
+}
+
+// authorizeAdmin lets only site admins through. Otherwise it responds with
+// 403 and returns false.
+func (h *handler) authorizeAdmin(context *gin.Context) bool {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return false
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not find user"})
+		return false
+	}
+	if user.Role != models.RoleAdmin {
+		context.JSON(http.StatusForbidden, gin.H{"message": "Only admins can do this"})
+		return false
+	}
+	return true
+}
+
+// authorizeReporter keeps accounts that have not verified their email from
+// posting lost and found reports, which is where spam does the most harm.
+func (h *handler) authorizeReporter(context *gin.Context, userId primitive.ObjectID, kind string) bool {
+	if kind != models.KindLost && kind != models.KindFound {
+		return true
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not find user"})
+		return false
+	}
+	if !user.EmailVerified() {
+		context.JSON(http.StatusForbidden, gin.H{"message": "Verify your email before posting lost or found reports", "error": "unverified"})
+		return false
+	}
+	return true
+}
+
+// authorizeGroup lets the request through when the caller holds one of roles
+// in the group or is a site admin. Otherwise it responds with 403.
+func (h *handler) authorizeGroup(context *gin.Context, group models.Group, roles ...string) bool {
+	userId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
+		return false
+	}
+	if group.HasRole(userId, roles...) {
+		return true
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err == nil && user.Role == models.RoleAdmin {
+		return true
+	}
+	context.JSON(http.StatusForbidden, gin.H{"message": "You are not allowed to manage this group"})
+	return false
+}
+
+// authorizeGroupRank lets the caller act on another member only when they
+// outrank both that member and any role being granted. Role changes need at
+// least admin, owners may hand over ownership, and site admins outrank all.
+func (h *handler) authorizeGroupRank(context *gin.Context, group models.Group, targetId primitive.ObjectID, role string) bool {
+	userId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
+		return false
+	}
+	rank := models.GroupRoleRank(group.RoleOf(userId))
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err == nil && user.Role == models.RoleAdmin {
+		rank = models.GroupRoleRank(models.GroupRoleOwner) + 1
+	}
+	minimum := models.GroupRoleRank(models.GroupRoleModerator)
+	if role != "" {
+		minimum = models.GroupRoleRank(models.GroupRoleAdmin)
+	}
+	allowed := rank >= minimum && rank > models.GroupRoleRank(group.RoleOf(targetId))
+	if role != "" && rank <= models.GroupRoleRank(role) && !(role == models.GroupRoleOwner && rank >= models.GroupRoleRank(models.GroupRoleOwner)) {
+		allowed = false
+	}
+	if !allowed {
+		context.JSON(http.StatusForbidden, gin.H{"message": "You are not allowed to manage this member"})
+	}
+	return allowed
+}
@@ -0,0 +1,271 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type messageRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+type contactRequest struct {
+	Share *bool `json:"share" binding:"required"`
+}
+
+func bindMessage(context *gin.Context) (models.Message, bool) {
+	var request messageRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Message{}, false
+	}
+	content := strings.TrimSpace(request.Content)
+	if content == "" || len(content) > models.MaxMessageLength {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Messages must be between 1 and 2000 characters"})
+		return models.Message{}, false
+	}
+	return models.Message{Content: content}, true
+}
+
+// findOwnConversation loads the conversation in the path and hides it behind
+// a 404 from anyone who is not taking part in it.
+func (h *handler) findOwnConversation(context *gin.Context) (models.Conversation, primitive.ObjectID, bool) {
+	conversationId, err := primitive.ObjectIDFromHex(context.Param("conversationId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Conversation{}, primitive.NilObjectID, false
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return models.Conversation{}, primitive.NilObjectID, false
+	}
+	conversation, err := h.store.Conversations.FindConversation(context, conversationId)
+	if err == nil {
+		if _, ok := conversation.Participant(userId); !ok {
+			err = models.ErrNotFound
+		}
+	}
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find conversation"})
+		return models.Conversation{}, primitive.NilObjectID, false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch conversation"})
+		return models.Conversation{}, primitive.NilObjectID, false
+	}
+	return conversation, userId, true
+}
+
+// authorizeContact refuses to connect two users when either has blocked the
+// other. The response does not say which side did the blocking.
+func (h *handler) authorizeContact(context *gin.Context, userId, otherId primitive.ObjectID) bool {
+	for _, pair := range [][2]primitive.ObjectID{{userId, otherId}, {otherId, userId}} {
+		user, err := h.store.Users.FindUserByID(context, pair[0])
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not send message"})
+			return false
+		}
+		if user.HasBlocked(pair[1]) {
+			context.JSON(http.StatusForbidden, gin.H{"message": "You cannot message this user"})
+			return false
+		}
+	}
+	return true
+}
+
+func (h *handler) deliverMessage(context *gin.Context, conversation models.Conversation, message models.Message, status int) {
+	result, err := h.store.Conversations.AddMessage(context, conversation.ID, message)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not send message"})
+		return
+	}
+	sent := result.Messages[len(result.Messages)-1]
+	recipient := result.Other(message.Sender).UserID
+	h.hub.Publish(events.Event{Type: events.MessageCreated, Audience: []primitive.ObjectID{recipient}, Data: gin.H{"conversation_id": result.ID, "message": sent}})
+	context.JSON(status, gin.H{"message": "Message sent", "conversation": result})
+}
+
+// startConversation messages the author of a report privately, reusing the
+// thread if the two already have one about it.
+func (h *handler) startConversation(context *gin.Context) {
+	message, ok := bindMessage(context)
+	if !ok {
+		return
+	}
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	if !models.IsReport(post.Kind) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Conversations can only be started about lost, found and sighting reports"})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	if userId == post.Creator {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "You cannot start a conversation with yourself"})
+		return
+	}
+	if !h.authorizeContact(context, userId, post.Creator) {
+		return
+	}
+	conversation, err := h.store.Conversations.FindPostConversation(context, post.ID, userId, post.Creator)
+	status := http.StatusOK
+	if err == models.ErrNotFound {
+		status = http.StatusCreated
+		conversation, err = h.store.Conversations.CreateConversation(context, models.Conversation{
+			PostID:       post.ID,
+			Participants: []models.Participant{{UserID: userId}, {UserID: post.Creator}},
+		})
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not start conversation"})
+		return
+	}
+	message.Sender = userId
+	h.deliverMessage(context, conversation, message, status)
+}
+
+func (h *handler) getConversations(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	conversations, err := h.store.Conversations.FindConversations(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch conversations. Try again later"})
+		return
+	}
+	summaries := []models.ConversationSummary{}
+	for _, conversation := range conversations {
+		summaries = append(summaries, conversation.Summary(userId))
+	}
+	context.JSON(http.StatusOK, gin.H{"conversations": summaries})
+}
+
+func (h *handler) getConversation(context *gin.Context) {
+	conversation, _, ok := h.findOwnConversation(context)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"conversation": conversation, "contact_shared": conversation.ContactShared()})
+}
+
+func (h *handler) sendMessage(context *gin.Context) {
+	message, ok := bindMessage(context)
+	if !ok {
+		return
+	}
+	conversation, userId, ok := h.findOwnConversation(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeContact(context, userId, conversation.Other(userId).UserID) {
+		return
+	}
+	message.Sender = userId
+	h.deliverMessage(context, conversation, message, http.StatusCreated)
+}
+
+// markConversationRead moves the caller's read receipt to now and tells the
+// other side.
+func (h *handler) markConversationRead(context *gin.Context) {
+	conversation, userId, ok := h.findOwnConversation(context)
+	if !ok {
+		return
+	}
+	result, err := h.store.Conversations.MarkConversationRead(context, conversation.ID, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update conversation"})
+		return
+	}
+	reader, _ := result.Participant(userId)
+	h.hub.Publish(events.Event{Type: events.ConversationRead, Audience: []primitive.ObjectID{result.Other(userId).UserID}, Data: gin.H{"conversation_id": result.ID, "user": userId, "last_read_at": reader.LastReadAt}})
+	context.JSON(http.StatusOK, gin.H{"message": "Conversation read", "conversation": result})
+}
+
+func (h *handler) shareContact(context *gin.Context) {
+	var request contactRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	conversation, userId, ok := h.findOwnConversation(context)
+	if !ok {
+		return
+	}
+	result, err := h.store.Conversations.SetContactSharing(context, conversation.ID, userId, *request.Share)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update conversation"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Contact sharing updated", "conversation": result, "contact_shared": result.ContactShared()})
+}
+
+// getContact reveals the other side's email and phone number, but only once
+// both participants have agreed to share theirs and neither has blocked the
+// other.
+func (h *handler) getContact(context *gin.Context) {
+	conversation, userId, ok := h.findOwnConversation(context)
+	if !ok {
+		return
+	}
+	otherId := conversation.Other(userId).UserID
+	if !h.authorizeContact(context, userId, otherId) {
+		return
+	}
+	if !conversation.ContactShared() {
+		context.JSON(http.StatusForbidden, gin.H{"message": "Contact details are shared once both sides agree", "participants": conversation.Participants})
+		return
+	}
+	other, err := h.store.Users.FindUserByID(context, otherId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch contact details"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"contact": gin.H{"_id": other.ID, "username": other.Username, "email": other.Email, "phone_number": other.PhoneNumber}})
+}
+
+func (h *handler) blockParticipant(blocked bool) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		conversation, userId, ok := h.findOwnConversation(context)
+		if !ok {
+			return
+		}
+		otherId := conversation.Other(userId).UserID
+		var err error
+		if blocked {
+			err = h.store.Users.BlockUser(context, userId, otherId)
+		} else {
+			err = h.store.Users.UnblockUser(context, userId, otherId)
+		}
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update blocked users"})
+			return
+		}
+		// Blocking withdraws both sides' agreement to share contact details,
+		// so unblocking later does not reveal them again by itself.
+		if blocked {
+			for _, participant := range conversation.Participants {
+				_, err = h.store.Conversations.SetContactSharing(context, conversation.ID, participant.UserID, false)
+				if err != nil {
+					context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update conversation"})
+					return
+				}
+			}
+		}
+		context.JSON(http.StatusOK, gin.H{"message": "Blocked users updated", "userId": otherId, "blocked": blocked})
+	}
+}
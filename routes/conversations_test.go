@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"pet-search-backend-go/models"
+	"testing"
+)
+
+func TestBlockingHidesSharedContact(t *testing.T) {
+	ts := newTestServer(t)
+	owner, ownerToken := ts.signUp("owner", models.RoleUser)
+	finder, finderToken := ts.signUp("finder", models.RoleUser)
+
+	// start has both sides agree to share contact details and returns the
+	// conversation's contact path.
+	start := func(t *testing.T) string {
+		t.Helper()
+		post := ts.postFixture(owner)
+		recorder := ts.do(http.MethodPost, "/feed/posts/"+post.ID.Hex()+"/conversations", finderToken, map[string]string{"content": "I think I saw your cat"})
+		expectStatus(t, recorder, http.StatusCreated)
+		var response struct {
+			Conversation models.Conversation `json:"conversation"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		path := "/conversations/" + response.Conversation.ID.Hex()
+		for _, token := range []string{ownerToken, finderToken} {
+			expectStatus(t, ts.do(http.MethodPost, path+"/contact", token, map[string]bool{"share": true}), http.StatusOK)
+		}
+		expectStatus(t, ts.do(http.MethodGet, path+"/contact", finderToken, nil), http.StatusOK)
+		return path
+	}
+
+	t.Run("block in conversation", func(t *testing.T) {
+		path := start(t)
+		expectStatus(t, ts.do(http.MethodPost, path+"/block", ownerToken, nil), http.StatusOK)
+		expectStatus(t, ts.do(http.MethodGet, path+"/contact", finderToken, nil), http.StatusForbidden)
+		expectStatus(t, ts.do(http.MethodGet, path+"/contact", ownerToken, nil), http.StatusForbidden)
+		expectStatus(t, ts.do(http.MethodPost, path+"/unblock", ownerToken, nil), http.StatusOK)
+		expectStatus(t, ts.do(http.MethodGet, path+"/contact", finderToken, nil), http.StatusForbidden)
+	})
+	t.Run("block elsewhere", func(t *testing.T) {
+		path := start(t)
+		if err := ts.store.Users.BlockUser(context.Background(), owner.ID, finder.ID); err != nil {
+			t.Fatal(err)
+		}
+		defer ts.store.Users.UnblockUser(context.Background(), owner.ID, finder.ID)
+		expectStatus(t, ts.do(http.MethodGet, path+"/contact", finderToken, nil), http.StatusForbidden)
+	})
+}
@@ -0,0 +1,125 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchRadius = 5000
+	maxSearchRadius     = 100000
+)
+
+// validateLocation checks the reported point and privacy radius. Reports must
+// say where the pet was last seen or found; other posts may omit it.
+func validateLocation(context *gin.Context, kind string, post models.Post, required bool) bool {
+	if post.Location == nil {
+		if required && models.IsReport(kind) {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Reports must include a location"})
+			return false
+		}
+		return true
+	}
+	if post.Location.Type == "" {
+		post.Location.Type = "Point"
+	}
+	if err := post.Location.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid location", "error": err.Error()})
+		return false
+	}
+	if post.FuzzRadius < 0 || post.FuzzRadius > models.MaxFuzzRadius {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Fuzz radius must be between 0 and 5000 meters"})
+		return false
+	}
+	return true
+}
+
+func floatQuery(context *gin.Context, names ...string) ([]float64, bool) {
+	values := make([]float64, len(names))
+	for index, name := range names {
+		value, err := strconv.ParseFloat(context.Query(name), 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Query parameter " + name + " must be a number"})
+			return nil, false
+		}
+		values[index] = value
+	}
+	return values, true
+}
+
+func (h *handler) getNearbyPosts(context *gin.Context) {
+	coordinates, ok := floatQuery(context, "lng", "lat")
+	if !ok {
+		return
+	}
+	center := models.NewPoint(coordinates[0], coordinates[1])
+	if err := center.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid location", "error": err.Error()})
+		return
+	}
+	radius := float64(defaultSearchRadius)
+	if context.Query("radius") != "" {
+		values, ok := floatQuery(context, "radius")
+		if !ok {
+			return
+		}
+		radius = values[0]
+	}
+	if radius <= 0 || radius > maxSearchRadius {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Radius must be between 0 and 100000 meters"})
+		return
+	}
+	filter, ok := postFilterFromQuery(context)
+	if !ok {
+		return
+	}
+	posts, err := h.store.Posts.FindPostsNear(context, center, radius, filter)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
+		return
+	}
+	visibility := h.newVisibility(context)
+	var visible []models.NearbyPost
+	for _, post := range posts {
+		ok, err := visibility.allows(post.Post)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
+			return
+		}
+		if ok {
+			visible = append(visible, post)
+		}
+	}
+	context.JSON(http.StatusOK, views.NearbyPosts(visible, h.viewer(context)))
+}
+
+func (h *handler) getPostsWithin(context *gin.Context) {
+	bounds, ok := floatQuery(context, "min_lng", "min_lat", "max_lng", "max_lat")
+	if !ok {
+		return
+	}
+	box := models.Box{MinLng: bounds[0], MinLat: bounds[1], MaxLng: bounds[2], MaxLat: bounds[3]}
+	if err := box.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid bounding box", "error": err.Error()})
+		return
+	}
+	filter, ok := postFilterFromQuery(context)
+	if !ok {
+		return
+	}
+	posts, err := h.store.Posts.FindPostsWithin(context, box, filter)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
+		return
+	}
+	posts, err = h.newVisibility(context).filter(posts)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
+		return
+	}
+	context.JSON(http.StatusOK, views.Posts(posts, h.viewer(context)))
+}
@@ -1,30 +1,154 @@
 package routes
 
 import (
+	"log"
 	"net/http"
 	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func getGroups(context *gin.Context) {
-	users, err := models.FindAllUsers()
+func (h *handler) findGroupFromParams(context *gin.Context) (models.Group, bool) {
+	groupId, err := primitive.ObjectIDFromHex(context.Param("groupId"))
 	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find users", "error": err})
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Group{}, false
+	}
+	group, err := h.store.Groups.FindGroup(context, groupId)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find group"})
+		return models.Group{}, false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch group"})
+		return models.Group{}, false
+	}
+	return group, true
+}
+
+func (h *handler) getGroups(context *gin.Context) {
+	groups, err := h.store.Groups.FindAllGroups(context)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch groups. Try again later", "error": err})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"groups": views.Groups(groups, h.viewer(context))})
+}
+
+func (h *handler) getGroup(context *gin.Context) {
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"group": views.Group(group, h.viewer(context))})
+}
+
+func (h *handler) createGroup(context *gin.Context) {
+	var group models.Group
+	err := context.ShouldBindJSON(&group)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	if group.GroupName == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Group name is required"})
+		return
+	}
+	userId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"users": users})
+	group.Members = []models.Member{{UserID: userId, Role: models.GroupRoleOwner, JoinedAt: time.Now()}}
+	newGroup, err := h.store.Groups.CreateGroup(context, group)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create group"})
+		return
+	}
+	err = h.store.Users.AddMembership(context, userId, newGroup.ID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not attach group to user account"})
+		return
+	}
+	context.JSON(http.StatusCreated, gin.H{"message": "Group created", "group": views.Group(newGroup, h.viewer(context))})
 }
 
-func getGroup(context *gin.Context) {
-	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
-	filter := bson.D{{Key: "_id", Value: userId}}
-	user, err := models.FindUser(filter)
+func (h *handler) editGroup(context *gin.Context) {
+	var changes models.GroupChanges
+	err := context.ShouldBindJSON(&changes)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin) {
+		return
+	}
+	if changes.GroupName != nil && *changes.GroupName == "" {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Group name is required"})
+		return
+	}
+	result, err := h.store.Groups.UpdateGroup(context, group.ID, changes)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update group"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Group updated", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) deleteGroup(context *gin.Context) {
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin) {
+		return
+	}
+	err := h.store.Groups.DeleteGroup(context, group.ID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete group"})
+		return
+	}
+	failed := false
+	for _, m := range group.Members {
+		err = h.store.Users.RemoveMembership(context, m.UserID, group.ID)
+		if err != nil && err != models.ErrNotFound {
+			log.Printf("could not remove group %s from user %s: %v", group.ID.Hex(), m.UserID.Hex(), err)
+			failed = true
+		}
+	}
+	if failed {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Group deleted, but could not remove it from every member's account"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Group deleted", "groupId": group.ID})
+}
+
+func (h *handler) getGroupPosts(context *gin.Context) {
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	viewer := h.newVisibility(context)
+	if group.Private && group.RoleOf(viewer.userId) == "" && !viewer.moderator {
+		context.JSON(http.StatusForbidden, gin.H{"message": "Only members can see posts in this group"})
+		return
+	}
+	filter, ok := postFilterFromQuery(context)
+	if !ok {
+		return
+	}
+	filter.Group = group.ID
+	posts, err := h.store.Posts.FindPosts(context, filter)
 	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"user": user})
+	context.JSON(http.StatusOK, views.Posts(posts, h.viewer(context)))
 }
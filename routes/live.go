@@ -0,0 +1,254 @@
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	liveHeartbeat    = 25 * time.Second
+	liveIdleTimeout  = 60 * time.Second
+	liveWriteTimeout = 10 * time.Second
+	typingInterval   = 2 * time.Second
+	presenceInterval = 2 * time.Second
+	// liveAccessCheck is how often an open connection re-checks that the
+	// viewer may still see the post, e.g. after leaving its private group.
+	liveAccessCheck = 30 * time.Second
+)
+
+var liveEventTypes = []string{events.CommentCreated, events.ReplyCreated, events.PostLiked, events.SightingCreated, events.Typing, events.Presence}
+
+// liveStates are the presence states a client may report. The server itself
+// announces "joined" and "left".
+var liveStates = []string{"active", "idle", "away"}
+
+// liveMessage is what the server sends over a post's WebSocket. Clients send
+// {"type": "typing"}, {"type": "presence", "state": "active"} (or "idle" or
+// "away") or {"type": "pong"} and must send something at least once a minute
+// to stay connected. Typing and presence are passed on at most once every two
+// seconds per connection.
+type liveMessage struct {
+	ID   uint64 `json:"id,omitempty"`
+	Type string `json:"type"`
+	Data any    `json:"data,omitempty"`
+}
+
+type liveInput struct {
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+type liveActivity struct {
+	PostID primitive.ObjectID `json:"post_id"`
+	User   primitive.ObjectID `json:"user"`
+	State  string             `json:"state,omitempty"`
+}
+
+// presence tracks who is watching each post on this instance. A user with
+// several tabs open counts once and leaves when their last tab closes.
+type presence struct {
+	mu      sync.Mutex
+	viewers map[primitive.ObjectID]map[primitive.ObjectID]int
+}
+
+func newPresence() *presence {
+	return &presence{viewers: map[primitive.ObjectID]map[primitive.ObjectID]int{}}
+}
+
+func (p *presence) join(postId, userId primitive.ObjectID) []primitive.ObjectID {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.viewers[postId] == nil {
+		p.viewers[postId] = map[primitive.ObjectID]int{}
+	}
+	p.viewers[postId][userId]++
+	var viewers []primitive.ObjectID
+	for viewer := range p.viewers[postId] {
+		viewers = append(viewers, viewer)
+	}
+	return viewers
+}
+
+// leave reports whether that was the user's last connection to the post.
+func (p *presence) leave(postId, userId primitive.ObjectID) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.viewers[postId][userId]--
+	if p.viewers[postId][userId] > 0 {
+		return false
+	}
+	delete(p.viewers[postId], userId)
+	if len(p.viewers[postId]) == 0 {
+		delete(p.viewers, postId)
+	}
+	return true
+}
+
+func (h *handler) publishActivity(kind string, post models.Post, userId primitive.ObjectID, state string) {
+	h.hub.Publish(events.Event{Type: kind, Post: &post, Data: liveActivity{PostID: post.ID, User: userId, State: state}, Transient: true})
+}
+
+// allowedOrigin reports whether a browser page at origin may open a live
+// socket: only the configured app may. Native clients send no Origin.
+func (h *handler) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	app, err := url.Parse(h.cfg.Mail.AppURL)
+	if err != nil {
+		return false
+	}
+	page, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(page.Scheme, app.Scheme) && strings.EqualFold(page.Host, app.Host)
+}
+
+// livePost upgrades to a WebSocket carrying the post's comments, replies,
+// likes and sightings as they happen, plus typing and presence indicators.
+// Authentication happens on the upgrade request, like any other route.
+func (h *handler) livePost(context *gin.Context) {
+	if !h.allowedOrigin(context.GetHeader("Origin")) {
+		context.JSON(http.StatusForbidden, gin.H{"message": "Origin is not allowed"})
+		return
+	}
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sessionId, ok := currentSessionId(context)
+	if !ok {
+		return
+	}
+	server := websocket.Server{
+		// The origin was checked above.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   func(conn *websocket.Conn) { h.serveLivePost(context, conn, post, userId, sessionId) },
+	}
+	server.ServeHTTP(context.Writer, context.Request)
+}
+
+// stillWatching re-checks that the viewer may follow the post: their session
+// is active and the post still exists and is visible to them. It only says
+// no when that is certain; a failed lookup keeps the connection open.
+func (h *handler) stillWatching(context *gin.Context, postId, sessionId primitive.ObjectID) (string, bool) {
+	session, err := h.store.Sessions.FindSession(context, sessionId)
+	if err == models.ErrNotFound || err == nil && !session.Active(time.Now()) {
+		return "Session has ended, please log in again", false
+	}
+	post, err := h.store.Posts.FindPost(context, postId)
+	if err == models.ErrNotFound {
+		return "Post was deleted", false
+	}
+	if err != nil {
+		return "", true
+	}
+	ok, err := h.newVisibility(context).allows(post)
+	if err == nil && !ok {
+		return "You can no longer see this post", false
+	}
+	return "", true
+}
+
+func (h *handler) serveLivePost(context *gin.Context, conn *websocket.Conn, post models.Post, userId, sessionId primitive.ObjectID) {
+	// The hub drops this subscription if it falls behind, so a slow client
+	// only ever costs its own connection.
+	subscription := h.hub.SubscribePost(post.ID)
+	defer subscription.Cancel()
+
+	viewers := h.presence.join(post.ID, userId)
+	h.publishActivity(events.Presence, post, userId, "joined")
+	defer func() {
+		if h.presence.leave(post.ID, userId) {
+			h.publishActivity(events.Presence, post, userId, "left")
+		}
+	}()
+
+	send := func(message liveMessage) error {
+		if err := conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout)); err != nil {
+			return err
+		}
+		return websocket.JSON.Send(conn, message)
+	}
+	if send(liveMessage{Type: "viewers", Data: gin.H{"post_id": post.ID, "viewers": viewers}}) != nil {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var lastTyping, lastPresence time.Time
+		for {
+			if err := conn.SetReadDeadline(time.Now().Add(liveIdleTimeout)); err != nil {
+				return
+			}
+			var input liveInput
+			if err := websocket.JSON.Receive(conn, &input); err != nil {
+				return
+			}
+			switch input.Type {
+			case events.Typing:
+				if time.Since(lastTyping) >= typingInterval {
+					lastTyping = time.Now()
+					h.publishActivity(events.Typing, post, userId, "")
+				}
+			case events.Presence:
+				if slices.Contains(liveStates, input.State) && time.Since(lastPresence) >= presenceInterval {
+					lastPresence = time.Now()
+					h.publishActivity(events.Presence, post, userId, input.State)
+				}
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	accessCheck := time.NewTicker(liveAccessCheck)
+	defer accessCheck.Stop()
+	for {
+		var err error
+		select {
+		case <-closed:
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				_ = send(liveMessage{Type: "error", Data: gin.H{"message": "Connection fell behind. Reconnect to catch up"}})
+				return
+			}
+			if !slices.Contains(liveEventTypes, event.Type) {
+				continue
+			}
+			if activity, ok := event.Data.(liveActivity); ok && activity.User == userId {
+				continue
+			}
+			err = send(liveMessage{ID: event.ID, Type: event.Type, Data: event.Data})
+		case <-heartbeat.C:
+			err = send(liveMessage{Type: "ping"})
+		case <-accessCheck.C:
+			if message, ok := h.stillWatching(context, post.ID, sessionId); !ok {
+				_ = send(liveMessage{Type: "error", Data: gin.H{"message": message}})
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func dialLive(t *testing.T, ts *testServer, server *httptest.Server, post models.Post, token string) *websocket.Conn {
+	t.Helper()
+	config, err := websocket.NewConfig("ws"+strings.TrimPrefix(server.URL, "http")+"/feed/posts/"+post.ID.Hex()+"/live", ts.h.cfg.Mail.AppURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Header.Set("Authorization", "Bearer "+token)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+func TestLivePresenceIsThrottledAndChecked(t *testing.T) {
+	ts := newTestServer(t)
+	owner, ownerToken := ts.signUp("owner", models.RoleUser)
+	_, otherToken := ts.signUp("other", models.RoleUser)
+	post := ts.postFixture(owner)
+	server := httptest.NewServer(ts.server)
+	defer server.Close()
+
+	watcher := dialLive(t, ts, server, post, otherToken)
+	defer watcher.Close()
+	conn := dialLive(t, ts, server, post, ownerToken)
+	defer conn.Close()
+
+	for i := 0; i < 20; i++ {
+		state := "active"
+		if i%4 == 0 {
+			state = "dancing"
+		}
+		if err := websocket.JSON.Send(conn, liveInput{Type: events.Presence, State: state}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var states []string
+	if err := watcher.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		var message struct {
+			Type string       `json:"type"`
+			Data liveActivity `json:"data"`
+		}
+		if err := websocket.JSON.Receive(watcher, &message); err != nil {
+			break
+		}
+		if message.Type == events.Presence && message.Data.State != "joined" {
+			states = append(states, message.Data.State)
+		}
+	}
+	if len(states) != 1 || states[0] != "active" {
+		t.Errorf("presence states passed on = %q, want just one active", states)
+	}
+}
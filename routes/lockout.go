@@ -0,0 +1,180 @@
+package routes
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"pet-search-backend-go/lockout"
+	"pet-search-backend-go/mail"
+	"pet-search-backend-go/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type unlockRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func retryAfter(context *gin.Context, wait time.Duration, message string) {
+	seconds := int(wait.Round(time.Second).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	context.Header("Retry-After", strconv.Itoa(seconds))
+	context.JSON(http.StatusTooManyRequests, gin.H{"message": message, "retry_after": seconds})
+}
+
+// loginAttempt is a login or second-factor attempt reserved against an
+// email and a client address.
+type loginAttempt struct {
+	email   string
+	ip      string
+	account lockout.Reservation
+	address lockout.Reservation
+}
+
+// allowLogin holds back attempts on an account or from an address that has
+// failed too often. Accounts are tracked by email whether or not one is
+// registered, so the throttling gives nothing away. The attempt is counted
+// as failed up front, so parallel requests cannot all get past the limits;
+// it is taken back by attemptSucceeded or refundLogin. Otherwise it responds
+// with 429 and returns false.
+func (h *handler) allowLogin(context *gin.Context, email string) (loginAttempt, bool) {
+	attempt := loginAttempt{email: email, ip: context.ClientIP()}
+	var err error
+	attempt.account, err = h.accountAttempts.Reserve(context, email)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log in user"})
+		return loginAttempt{}, false
+	}
+	if attempt.account.Locked {
+		retryAfter(context, attempt.account.Wait, "Too many failed attempts, this account is locked for now. Check your email for a link to unlock it")
+		return loginAttempt{}, false
+	}
+	if attempt.account.Wait > 0 {
+		retryAfter(context, attempt.account.Wait, "Too many failed attempts, try again later")
+		return loginAttempt{}, false
+	}
+	attempt.address, err = h.addressAttempts.Reserve(context, attempt.ip)
+	if err == nil && attempt.address.Wait > 0 {
+		h.refund(context, h.accountAttempts, email)
+		retryAfter(context, attempt.address.Wait, "Too many failed attempts from this address, try again later")
+		return loginAttempt{}, false
+	}
+	if err != nil {
+		h.refund(context, h.accountAttempts, email)
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log in user"})
+		return loginAttempt{}, false
+	}
+	return attempt, true
+}
+
+func (h *handler) refund(context *gin.Context, limiter *lockout.Limiter, key string) {
+	if err := limiter.Refund(context, key); err != nil {
+		log.Printf("could not refund login attempt: %v", err)
+	}
+}
+
+// refundLogin takes back an attempt that neither failed nor completed the
+// login, such as a correct password still waiting for its second factor.
+func (h *handler) refundLogin(context *gin.Context, attempt loginAttempt) {
+	h.refund(context, h.accountAttempts, attempt.email)
+	h.refund(context, h.addressAttempts, attempt.ip)
+}
+
+// attemptSucceeded forgets the account's failures and takes back the
+// address's reserved attempt; other logins from the address still count.
+func (h *handler) attemptSucceeded(context *gin.Context, attempt loginAttempt) {
+	if err := h.accountAttempts.Reset(context, attempt.email); err != nil {
+		log.Printf("could not clear failed logins after a successful login: %v", err)
+	}
+	h.refund(context, h.addressAttempts, attempt.ip)
+}
+
+// loginFailed handles the lockouts a failed attempt caused; the failure
+// itself was already counted by allowLogin. When the account is locked its
+// owner, if there is one, is emailed an unlock link; every lockout is
+// written to the audit log.
+func (h *handler) loginFailed(context *gin.Context, attempt loginAttempt, user *models.User) {
+	if attempt.account.Locks {
+		record := attempt.account.Record
+		event := models.AuditEvent{Type: models.AuditAccountLocked, Email: attempt.email, IP: attempt.ip, Detail: fmt.Sprintf("locked until %s after %d failed attempts", record.LockedUntil.Format(time.RFC3339), record.Failures)}
+		if user != nil {
+			event.UserID = user.ID
+			if err := h.sendUnlock(context, *user); err != nil {
+				log.Printf("could not send unlock email to user %s: %v", user.ID.Hex(), err)
+			}
+		}
+		h.audit(context, event)
+	}
+	if attempt.address.Locks {
+		record := attempt.address.Record
+		h.audit(context, models.AuditEvent{Type: models.AuditAddressLocked, Email: attempt.email, IP: attempt.ip, Detail: fmt.Sprintf("locked until %s after %d failed attempts", record.LockedUntil.Format(time.RFC3339), record.Failures)})
+	}
+}
+
+// audit records event, logging instead of failing the request if it cannot.
+// The log line names the account by id only; the submitted email and the
+// client address stay in the audit store.
+func (h *handler) audit(context *gin.Context, event models.AuditEvent) {
+	user := "unknown"
+	if !event.UserID.IsZero() {
+		user = event.UserID.Hex()
+	}
+	log.Printf("audit: %s user=%s %s", event.Type, user, event.Detail)
+	if err := h.store.Audit.RecordAuditEvent(context, event); err != nil {
+		log.Printf("could not record audit event %s: %v", event.Type, err)
+	}
+}
+
+func (h *handler) sendUnlock(context *gin.Context, user models.User) error {
+	token, hash, err := models.NewToken()
+	if err != nil {
+		return err
+	}
+	_, err = h.store.AccountTokens.CreateAccountToken(context, models.AccountToken{UserID: user.ID, Purpose: models.TokenAccountUnlock, Hash: hash, ExpiresAt: time.Now().Add(models.AccountUnlockTTL)})
+	if err != nil {
+		return err
+	}
+	h.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Your Pet Search account was locked",
+		Body: fmt.Sprintf("Hi %s,\n\nThere were too many failed attempts to log in to your Pet Search account, so we locked it for now. "+
+			"If that was you, open this link within %d minutes to unlock it:\n\n%s\n\n"+
+			"If it was not you, someone may be guessing your password. Your account stays locked for a while on its own; "+
+			"consider resetting your password.\n",
+			user.Username, int(models.AccountUnlockTTL.Minutes()), h.appLink("/unlock", token)),
+	})
+	return nil
+}
+
+func (h *handler) unlockAccount(context *gin.Context) {
+	var request unlockRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	token, err := h.store.AccountTokens.ConsumeAccountToken(context, models.TokenAccountUnlock, models.HashToken(request.Token))
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Unlock link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not unlock account"})
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, token.UserID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not unlock account"})
+		return
+	}
+	if err := h.accountAttempts.Reset(context, models.NormalizeEmail(user.Email)); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not unlock account"})
+		return
+	}
+	h.audit(context, models.AuditEvent{Type: models.AuditAccountUnlocked, UserID: user.ID, Email: user.Email, IP: context.ClientIP(), Detail: "unlocked by email link"})
+	context.JSON(http.StatusOK, gin.H{"message": "Account unlocked"})
+}
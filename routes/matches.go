@@ -0,0 +1,141 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type matchResult struct {
+	Match     models.Match `json:"match"`
+	Candidate models.Post  `json:"candidate"`
+}
+
+// refreshMatches scores an open lost or found report against open reports of
+// the opposite kind nearby. Matching is best effort: a failure is logged and
+// never fails the request that triggered it.
+func (h *handler) refreshMatches(context *gin.Context, post models.Post) {
+	kind := models.MatchCandidateKind(post.Kind)
+	if kind == "" || post.Status != models.StatusOpen || post.Pet == nil || post.Location == nil {
+		return
+	}
+	filter := models.PostFilter{Kind: kind, Status: models.StatusOpen, Species: post.Pet.Species}
+	candidates, err := h.store.Posts.FindPostsNear(context, *post.Location, models.MaxMatchDistance, filter)
+	if err != nil {
+		log.Printf("Could not find match candidates for post %s: %v", post.ID.Hex(), err)
+		return
+	}
+	var matches []models.Match
+	for _, candidate := range candidates {
+		lost, found := post, candidate.Post
+		if post.Kind == models.KindFound {
+			lost, found = candidate.Post, post
+		}
+		if match, ok := models.ScoreMatch(lost, found); ok {
+			matches = append(matches, match)
+		}
+	}
+	if err := h.store.Matches.SaveMatches(context, matches); err != nil {
+		log.Printf("Could not save matches for post %s: %v", post.ID.Hex(), err)
+	}
+}
+
+func (h *handler) findPostMatch(context *gin.Context) (models.Match, bool) {
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Match{}, false
+	}
+	matchId, err := primitive.ObjectIDFromHex(context.Param("matchId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Match{}, false
+	}
+	if _, ok := h.findVisiblePost(context, params.PostId); !ok {
+		return models.Match{}, false
+	}
+	match, err := h.store.Matches.FindMatch(context, matchId)
+	if err == models.ErrNotFound || (err == nil && match.LostPost != params.PostId && match.FoundPost != params.PostId) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find match"})
+		return models.Match{}, false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch match"})
+		return models.Match{}, false
+	}
+	return match, true
+}
+
+func (h *handler) getPostMatches(context *gin.Context) {
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	matches, err := h.store.Matches.FindMatches(context, post.ID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch matches. Try again later"})
+		return
+	}
+	status := context.Query("status")
+	visibility := h.newVisibility(context)
+	viewer := h.viewer(context)
+	results := []matchResult{}
+	for _, match := range matches {
+		if status != "" && match.Status != status {
+			continue
+		}
+		candidateId := match.FoundPost
+		if post.ID == match.FoundPost {
+			candidateId = match.LostPost
+		}
+		candidate, err := h.store.Posts.FindPost(context, candidateId)
+		if err == models.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch matches. Try again later"})
+			return
+		}
+		ok, err := visibility.allows(candidate)
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch matches. Try again later"})
+			return
+		}
+		if ok {
+			results = append(results, matchResult{Match: match, Candidate: views.Post(candidate, viewer)})
+		}
+	}
+	context.JSON(http.StatusOK, results)
+}
+
+func (h *handler) decideMatch(decision string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		match, ok := h.findPostMatch(context)
+		if !ok {
+			return
+		}
+		userId, ok := currentUserId(context)
+		if !ok {
+			return
+		}
+		if !match.Involves(userId) {
+			context.JSON(http.StatusForbidden, gin.H{"message": "Only the owner and the finder can review this match"})
+			return
+		}
+		result, err := h.store.Matches.AddMatchFeedback(context, match.ID, models.MatchFeedback{UserID: userId, Decision: decision})
+		if err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update match"})
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"message": "Match " + decision, "match": result})
+	}
+}
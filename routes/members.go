@@ -0,0 +1,314 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type invitationRequest struct {
+	UserID primitive.ObjectID `json:"user_id" binding:"required"`
+}
+
+type roleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func currentUserId(context *gin.Context) (primitive.ObjectID, bool) {
+	userId, err := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
+		return primitive.NilObjectID, false
+	}
+	return userId, true
+}
+
+func memberIdFromParams(context *gin.Context) (primitive.ObjectID, bool) {
+	userId, err := primitive.ObjectIDFromHex(context.Param("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return primitive.NilObjectID, false
+	}
+	return userId, true
+}
+
+// admitMember adds the user to the group and records the group on the user.
+func (h *handler) admitMember(context *gin.Context, group models.Group, userId primitive.ObjectID) (models.Group, bool) {
+	result, err := h.store.Groups.AddMember(context, group.ID, models.Member{UserID: userId, Role: models.GroupRoleMember})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not add member"})
+		return models.Group{}, false
+	}
+	err = h.store.Users.AddMembership(context, userId, group.ID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not attach group to user account"})
+		return models.Group{}, false
+	}
+	return result, true
+}
+
+func (h *handler) dropMember(context *gin.Context, group models.Group, userId primitive.ObjectID) (models.Group, bool) {
+	result, err := h.store.Groups.RemoveMember(context, group.ID, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not remove member"})
+		return models.Group{}, false
+	}
+	err = h.store.Users.RemoveMembership(context, userId, group.ID)
+	if err != nil && err != models.ErrNotFound {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not remove group from user account"})
+		return models.Group{}, false
+	}
+	return result, true
+}
+
+func (h *handler) joinGroup(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if group.RoleOf(userId) != "" {
+		context.JSON(http.StatusConflict, gin.H{"message": "Already a member of this group"})
+		return
+	}
+	if group.IsInvited(userId) {
+		result, ok := h.admitMember(context, group, userId)
+		if !ok {
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"message": "Joined group", "group": views.Group(result, h.viewer(context))})
+		return
+	}
+	if group.HasRequested(userId) {
+		context.JSON(http.StatusConflict, gin.H{"message": "Join request already pending"})
+		return
+	}
+	result, err := h.store.Groups.AddJoinRequest(context, group.ID, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request to join group"})
+		return
+	}
+	context.JSON(http.StatusAccepted, gin.H{"message": "Join request sent", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) inviteMember(context *gin.Context) {
+	var invitation invitationRequest
+	err := context.ShouldBindJSON(&invitation)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin, models.GroupRoleModerator) {
+		return
+	}
+	if _, err := h.store.Users.FindUserByID(context, invitation.UserID); err != nil {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+		return
+	}
+	if group.RoleOf(invitation.UserID) != "" {
+		context.JSON(http.StatusConflict, gin.H{"message": "User is already a member of this group"})
+		return
+	}
+	if group.HasRequested(invitation.UserID) {
+		result, ok := h.admitMember(context, group, invitation.UserID)
+		if !ok {
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"message": "Member added", "group": views.Group(result, h.viewer(context))})
+		return
+	}
+	result, err := h.store.Groups.AddInvitation(context, group.ID, models.Invitation{UserID: invitation.UserID, InvitedBy: userId})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not invite user"})
+		return
+	}
+	context.JSON(http.StatusCreated, gin.H{"message": "Invitation sent", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) cancelInvitation(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	inviteeId, ok := memberIdFromParams(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !group.IsInvited(inviteeId) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find invitation"})
+		return
+	}
+	if userId != inviteeId && !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin, models.GroupRoleModerator) {
+		return
+	}
+	result, err := h.store.Groups.RemoveInvitation(context, group.ID, inviteeId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not remove invitation"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Invitation removed", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) approveJoinRequest(context *gin.Context) {
+	requesterId, ok := memberIdFromParams(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin, models.GroupRoleModerator) {
+		return
+	}
+	if !group.HasRequested(requesterId) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find join request"})
+		return
+	}
+	result, ok := h.admitMember(context, group, requesterId)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Join request approved", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) denyJoinRequest(context *gin.Context) {
+	requesterId, ok := memberIdFromParams(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if !h.authorizeGroup(context, group, models.GroupRoleOwner, models.GroupRoleAdmin, models.GroupRoleModerator) {
+		return
+	}
+	if !group.HasRequested(requesterId) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find join request"})
+		return
+	}
+	result, err := h.store.Groups.RemoveJoinRequest(context, group.ID, requesterId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not deny join request"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Join request denied", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) changeMemberRole(context *gin.Context) {
+	var request roleRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil || !models.IsGroupRole(request.Role) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Role must be one of owner, admin, moderator or member"})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	memberId, ok := memberIdFromParams(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	if group.RoleOf(memberId) == "" {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find member"})
+		return
+	}
+	if userId == memberId {
+		context.JSON(http.StatusForbidden, gin.H{"message": "You cannot change your own role"})
+		return
+	}
+	if !h.authorizeGroupRank(context, group, memberId, request.Role) {
+		return
+	}
+	result, err := h.store.Groups.SetMemberRole(context, group.ID, memberId, request.Role)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not change member role"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Member role updated", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) removeMember(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	memberId, ok := memberIdFromParams(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	role := group.RoleOf(memberId)
+	if role == "" {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find member"})
+		return
+	}
+	if userId == memberId {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Use leave to remove yourself from a group"})
+		return
+	}
+	if role == models.GroupRoleOwner {
+		context.JSON(http.StatusForbidden, gin.H{"message": "The group owner cannot be removed"})
+		return
+	}
+	if !h.authorizeGroupRank(context, group, memberId, "") {
+		return
+	}
+	result, ok := h.dropMember(context, group, memberId)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Member removed", "group": views.Group(result, h.viewer(context))})
+}
+
+func (h *handler) leaveGroup(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	group, ok := h.findGroupFromParams(context)
+	if !ok {
+		return
+	}
+	role := group.RoleOf(userId)
+	if role == "" {
+		context.JSON(http.StatusNotFound, gin.H{"message": "You are not a member of this group"})
+		return
+	}
+	if role == models.GroupRoleOwner {
+		context.JSON(http.StatusConflict, gin.H{"message": "Transfer ownership before leaving the group"})
+		return
+	}
+	_, ok = h.dropMember(context, group, userId)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Left group", "groupId": group.ID})
+}
@@ -0,0 +1,199 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultNotificationPage = 50
+
+type preferencesRequest struct {
+	Preferences map[string]bool `json:"preferences" binding:"required"`
+}
+
+// notify stores the notifications, dropping any addressed to the user who
+// caused them or to recipients who muted that type. It is best effort: a
+// failure is logged and never fails the request that triggered it.
+func (h *handler) notify(context *gin.Context, notifications ...models.Notification) {
+	wants := map[primitive.ObjectID]models.User{}
+	var deliver []models.Notification
+	for _, n := range notifications {
+		if n.UserID == n.ActorID && !n.ActorID.IsZero() {
+			continue
+		}
+		recipient, ok := wants[n.UserID]
+		if !ok {
+			var err error
+			recipient, err = h.store.Users.FindUserByID(context, n.UserID)
+			if err != nil {
+				continue
+			}
+			wants[n.UserID] = recipient
+		}
+		if recipient.WantsNotification(n.Type) {
+			deliver = append(deliver, n)
+		}
+	}
+	created, err := h.store.Notifications.CreateNotifications(context, deliver)
+	if err != nil {
+		log.Printf("Could not create notifications: %v", err)
+		return
+	}
+	for _, n := range created {
+		h.hub.Publish(events.Event{Type: events.Notification, Audience: []primitive.ObjectID{n.UserID}, Data: n})
+	}
+}
+
+func (h *handler) getNotifications(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	limit := defaultNotificationPage
+	if value := context.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > models.MaxNotificationPage {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Limit must be between 1 and 100"})
+			return
+		}
+		limit = parsed
+	}
+	filter := models.NotificationFilter{UserID: userId, UnreadOnly: context.Query("unread") == "true", Limit: limit}
+	notifications, err := h.store.Notifications.FindNotifications(context, filter)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch notifications. Try again later"})
+		return
+	}
+	unread, err := h.store.Notifications.CountUnread(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch notifications. Try again later"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"notifications": notifications, "unread": unread})
+}
+
+func (h *handler) getUnreadCount(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	unread, err := h.store.Notifications.CountUnread(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not count notifications. Try again later"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"unread": unread})
+}
+
+func (h *handler) markNotificationRead(context *gin.Context) {
+	notificationId, err := primitive.ObjectIDFromHex(context.Param("notificationId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	notification, err := h.store.Notifications.MarkRead(context, userId, notificationId)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find notification"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update notification"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Notification read", "notification": notification})
+}
+
+func (h *handler) markAllNotificationsRead(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	count, err := h.store.Notifications.MarkAllRead(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update notifications"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Notifications read", "updated": count})
+}
+
+func notificationPreferences(user models.User) map[string]bool {
+	preferences := map[string]bool{}
+	for _, kind := range models.NotificationTypes {
+		preferences[kind] = user.WantsNotification(kind)
+	}
+	return preferences
+}
+
+func (h *handler) getNotificationPreferences(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch preferences"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"preferences": notificationPreferences(user)})
+}
+
+// updateNotificationPreferences merges the given types into the user's
+// current preferences; types left out keep their setting.
+func (h *handler) updateNotificationPreferences(context *gin.Context) {
+	var request preferencesRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	for kind := range request.Preferences {
+		if !models.IsNotificationType(kind) {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Unknown notification type", "type": kind, "types": models.NotificationTypes})
+			return
+		}
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update preferences"})
+		return
+	}
+	var muted []string
+	for _, kind := range models.NotificationTypes {
+		enabled, ok := request.Preferences[kind]
+		if !ok {
+			enabled = user.WantsNotification(kind)
+		}
+		if !enabled {
+			muted = append(muted, kind)
+		}
+	}
+	if err := h.store.Users.SetMutedNotifications(context, userId, muted); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update preferences"})
+		return
+	}
+	user.MutedNotifications = muted
+	context.JSON(http.StatusOK, gin.H{"message": "Preferences updated", "preferences": notificationPreferences(user)})
+}
+
+// notifyLike tells the author when someone likes their post. Unliking, which
+// is the same toggle, stays silent.
+func (h *handler) notifyLike(context *gin.Context, post models.Post, userId primitive.ObjectID) {
+	if !slices.Contains(post.Likes, userId) {
+		return
+	}
+	h.notify(context, models.Notification{UserID: post.Creator, Type: models.NotificationPostLiked, ActorID: userId, PostID: post.ID, Message: "Someone liked your post " + post.Title})
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"pet-search-backend-go/mail"
+	"pet-search-backend-go/models"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mailTimeout bounds delivery of an email sent after its request returned.
+const mailTimeout = 30 * time.Second
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// sendMail delivers message in the background so a slow mail server does
+// not hold up the request, or reveal by its timing that an email was sent.
+func (h *handler) sendMail(message mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := h.mailer.Send(ctx, message); err != nil {
+			log.Printf("could not send %q email: %v", message.Subject, err)
+		}
+	}()
+}
+
+// appLink builds a link into the web app carrying token.
+func (h *handler) appLink(path, token string) string {
+	return strings.TrimSuffix(h.cfg.Mail.AppURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// forgotPassword emails a reset link when the address belongs to an account.
+// The response is the same either way so it cannot be used to find out who
+// is registered. Requests are limited per client address, and an account is
+// sent no more reset emails than verification emails; past that limit the
+// request is accepted but nothing is sent, since a 429 would reveal that the
+// account exists. A new link invalidates the earlier unused ones.
+func (h *handler) forgotPassword(context *gin.Context) {
+	var request forgotPasswordRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	reservation, err := h.resetAddresses.Reserve(context, context.ClientIP())
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request password reset"})
+		return
+	}
+	if reservation.Wait > 0 {
+		retryAfter(context, reservation.Wait, "Please wait before requesting another password reset")
+		return
+	}
+	accepted := gin.H{"message": "If an account uses that email, a password reset link has been sent to it"}
+	user, err := h.store.Users.FindUserByEmail(context, models.NormalizeEmail(request.Email))
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusAccepted, accepted)
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request password reset"})
+		return
+	}
+	wait, err := h.emailRetryAfter(context, user, models.TokenPasswordReset)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request password reset"})
+		return
+	}
+	if wait > 0 {
+		context.JSON(http.StatusAccepted, accepted)
+		return
+	}
+	token, hash, err := models.NewToken()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request password reset"})
+		return
+	}
+	_, err = h.store.AccountTokens.CreateAccountToken(context, models.AccountToken{UserID: user.ID, Purpose: models.TokenPasswordReset, Hash: hash, ExpiresAt: time.Now().Add(models.PasswordResetTTL)})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not request password reset"})
+		return
+	}
+	h.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Pet Search password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your Pet Search account. "+
+			"If it was you, open this link within %d minutes to choose a new one:\n\n%s\n\n"+
+			"If it was not you, you can ignore this email; your password has not changed.\n",
+			user.Username, int(models.PasswordResetTTL.Minutes()), h.appLink("/reset-password", token)),
+	})
+	context.JSON(http.StatusAccepted, accepted)
+}
+
+// resetPassword sets a new password from an emailed token and signs the
+// account out everywhere, in case the old password was how someone got in.
+func (h *handler) resetPassword(context *gin.Context) {
+	var request resetPasswordRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	hash := models.HashToken(request.Token)
+	// The password is checked against the account before the token is spent,
+	// with the same rules as at signup, so a rejected password can be retried.
+	token, err := h.store.AccountTokens.FindAccountToken(context, models.TokenPasswordReset, hash)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not reset password"})
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, token.UserID)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not reset password"})
+		return
+	}
+	if err := models.ValidatePassword(request.Password, user); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid password", "error": err.Error()})
+		return
+	}
+	token, err = h.store.AccountTokens.ConsumeAccountToken(context, models.TokenPasswordReset, hash)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Reset link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not reset password"})
+		return
+	}
+	err = h.store.Users.SetPassword(context, token.UserID, request.Password)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not reset password"})
+		return
+	}
+	// A new password makes any lockout from guessing the old one moot.
+	if err := h.accountAttempts.Reset(context, models.NormalizeEmail(user.Email)); err != nil {
+		log.Printf("could not clear failed logins of user %s after password reset: %v", token.UserID.Hex(), err)
+	}
+	// Following the emailed link proved the address as much as verifying it would.
+	if err := h.store.Users.SetEmailVerified(context, token.UserID); err != nil {
+		log.Printf("could not mark email of user %s verified after password reset: %v", token.UserID.Hex(), err)
+	}
+	if _, err := h.store.Sessions.RevokeUserSessions(context, token.UserID); err != nil {
+		log.Printf("could not revoke sessions of user %s after password reset: %v", token.UserID.Hex(), err)
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
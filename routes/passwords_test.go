@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"pet-search-backend-go/models"
+	"testing"
+	"time"
+)
+
+func TestResetPasswordAppliesSignupRules(t *testing.T) {
+	ts := newTestServer(t)
+	user, _ := ts.signUp("rosalind", models.RoleUser)
+	token, hash, err := models.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ts.store.AccountTokens.CreateAccountToken(context.Background(), models.AccountToken{UserID: user.ID, Purpose: models.TokenPasswordReset, Hash: hash, ExpiresAt: time.Now().Add(models.PasswordResetTTL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range []string{"Rosalind-2024", "my-rosalind-pw1"} {
+		recorder := ts.do(http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "password": password})
+		expectStatus(t, recorder, http.StatusBadRequest)
+	}
+	// A rejected password leaves the link usable.
+	recorder := ts.do(http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "password": "Another-horse7"})
+	expectStatus(t, recorder, http.StatusOK)
+	recorder = ts.do(http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": "Another-horse7"})
+	expectStatus(t, recorder, http.StatusAccepted)
+}
@@ -1,11 +1,14 @@
 package routes
 
 import (
+	"log"
 	"net/http"
+	"pet-search-backend-go/events"
 	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+	"slices"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -34,84 +37,179 @@ func getIdsFromParams(context *gin.Context) (params, error) {
 	return params{PostId: postId, CommentId: commentId, replyId: replyId}, nil
 }
 
-func updateUserPosts(context *gin.Context, post models.Post) {
-	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
-	filter := bson.D{{Key: "_id", Value: userId}}
-	user, err := models.FindUser(filter)
+func (h *handler) updateUserPosts(context *gin.Context, post models.Post) {
+	err := h.store.Users.UpdateUserPost(context, post.Creator, post)
 	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not attach post to user account"})
 		return
 	}
-	err = user.UpdateUserPosts(post)
+}
+
+func (h *handler) deleteUserPost(context *gin.Context, post models.Post) {
+	err := h.store.Users.DeleteUserPost(context, post.Creator, post.ID)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not attach post to user account"})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not remove post from user account"})
 		return
 	}
 }
 
-func deleteUserPost(context *gin.Context, postId primitive.ObjectID) {
-	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
-	filter := bson.D{{Key: "_id", Value: userId}}
-	user, err := models.FindUser(filter)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+// authorizePostGroups checks that every target group exists and that the
+// author belongs to it, dropping duplicate group ids along the way.
+func (h *handler) authorizePostGroups(context *gin.Context, post *models.Post) bool {
+	var groups []primitive.ObjectID
+	for _, groupId := range post.Groups {
+		if slices.Contains(groups, groupId) {
+			continue
+		}
+		group, err := h.store.Groups.FindGroup(context, groupId)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Could not find group", "groupId": groupId})
+			return false
+		}
+		if group.RoleOf(post.Creator) == "" {
+			context.JSON(http.StatusForbidden, gin.H{"message": "You can only post to groups you belong to", "groupId": groupId})
+			return false
+		}
+		groups = append(groups, groupId)
+	}
+	post.Groups = groups
+	return true
+}
+
+type statusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// validatePet normalizes the pet description in place and rejects values
+// outside the controlled vocabularies or on posts that are not reports.
+func validatePet(context *gin.Context, kind string, pet *models.Pet) bool {
+	if pet == nil {
+		return true
+	}
+	if !models.IsReport(kind) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Only lost, found and sighting reports can describe a pet"})
+		return false
+	}
+	pet.Normalize()
+	if err := pet.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid pet description", "error": err.Error()})
+		return false
+	}
+	return true
+}
+
+func postFilterFromQuery(context *gin.Context) (models.PostFilter, bool) {
+	pet := models.Pet{
+		Species:   context.Query("species"),
+		Breed:     context.Query("breed"),
+		Colors:    context.QueryArray("color"),
+		Markings:  context.QueryArray("marking"),
+		Size:      context.Query("size"),
+		Sex:       context.Query("sex"),
+		Age:       context.Query("age"),
+		Microchip: context.Query("microchip"),
+	}
+	pet.Normalize()
+	if err := pet.ValidateVocabulary(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid pet filter", "error": err.Error()})
+		return models.PostFilter{}, false
+	}
+	filter := models.PostFilter{
+		Kind:      context.Query("kind"),
+		Status:    context.Query("status"),
+		Species:   pet.Species,
+		Breed:     pet.Breed,
+		Colors:    pet.Colors,
+		Markings:  pet.Markings,
+		Size:      pet.Size,
+		Sex:       pet.Sex,
+		Age:       pet.Age,
+		Microchip: pet.Microchip,
+	}
+	if filter.Kind != "" && !models.IsReportKind(filter.Kind) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Kind must be one of lost, found, sighting or general"})
+		return models.PostFilter{}, false
+	}
+	if filter.Status != "" && !models.IsReportStatus(filter.Status) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Status must be one of open, reunited, closed or expired"})
+		return models.PostFilter{}, false
+	}
+	return filter, true
+}
+
+func (h *handler) getPosts(context *gin.Context) {
+	filter, ok := postFilterFromQuery(context)
+	if !ok {
 		return
 	}
-	err = user.DeleteUserPost(postId)
+	posts, err := h.store.Posts.FindPosts(context, filter)
 	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not remove post from user account"})
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
 		return
 	}
-}
-
-func getPosts(context *gin.Context) {
-	posts, err := models.FindAllPosts()
+	posts, err = h.newVisibility(context).filter(posts)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch posts. Try again later", "error": err})
 		return
 	}
-	context.JSON(http.StatusOK, posts)
+	context.JSON(http.StatusOK, views.Posts(posts, h.viewer(context)))
 }
 
-func getPost(context *gin.Context) {
+func (h *handler) getPost(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
-	context.JSON(http.StatusOK, post)
+	context.JSON(http.StatusOK, views.Post(post, h.viewer(context)))
 }
 
-func createPost(context *gin.Context) {
+func (h *handler) createPost(context *gin.Context) {
 	var post models.Post
 	err := context.ShouldBindJSON(&post)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
+	if post.Kind != "" && !models.IsReportKind(post.Kind) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Kind must be one of lost, found, sighting or general"})
+		return
+	}
+	if !validatePet(context, post.Kind, post.Pet) {
+		return
+	}
+	if !validateLocation(context, post.Kind, post, true) {
+		return
+	}
 	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
 	post.Creator = userId
-	newPost, err := post.Create()
+	if !h.authorizeReporter(context, userId, post.Kind) {
+		return
+	}
+	if !h.authorizePostGroups(context, &post) {
+		return
+	}
+	newPost, err := h.store.Posts.CreatePost(context, post)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not create post"})
 		return
 	}
-	filter := bson.D{{Key: "_id", Value: userId}}
-	user, err := models.FindUser(filter)
+	err = h.store.Users.AddUserPost(context, userId, newPost)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not attach post to user account"})
 		return
 	}
-	user.AddPost(newPost)
-	context.JSON(http.StatusCreated, gin.H{"message": "Post created", "post": newPost})
+	h.refreshMatches(context, newPost)
+	h.notifyWatchers(context, newPost)
+	h.publish(events.PostCreated, newPost, nil, newPost)
+	context.JSON(http.StatusCreated, gin.H{"message": "Post created", "post": views.Post(newPost, h.viewer(context))})
 }
 
-func editPost(context *gin.Context) {
+func (h *handler) editPost(context *gin.Context) {
 	var updatedPost models.Post
 	err := context.ShouldBindJSON(&updatedPost)
 	if err != nil {
@@ -123,58 +221,78 @@ func editPost(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	if !h.authorizeOwner(context, post.Creator) {
+		return
+	}
+	if !validatePet(context, post.Kind, updatedPost.Pet) {
+		return
+	}
+	if !validateLocation(context, post.Kind, updatedPost, false) {
 		return
 	}
-	updatedPost.CreatedAt = post.CreatedAt
 	updatedPost.ID = params.PostId
-	result, err := updatedPost.Update(updatedPost)
+	result, err := h.store.Posts.UpdatePost(context, updatedPost)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update post"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Post Updated", "post": result})
+	h.updateUserPosts(context, result)
+	h.refreshMatches(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Post Updated", "post": views.Post(result, h.viewer(context))})
 }
 
-func deletePost(context *gin.Context) {
+func (h *handler) deletePost(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	result, err := models.Delete(params.PostId)
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	if !h.authorizeOwner(context, post.Creator) {
+		return
+	}
+	err = h.store.Posts.DeletePost(context, params.PostId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to delete post"})
 		return
 	}
-	deleteUserPost(context, params.PostId)
-	context.JSON(http.StatusOK, gin.H{"message": "Post deleted", "post": result})
+	h.deleteUserPost(context, post)
+	if err := h.store.Matches.DeletePostMatches(context, post.ID); err != nil {
+		log.Printf("Could not delete matches for post %s: %v", post.ID.Hex(), err)
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Post deleted", "postId": params.PostId})
 }
 
-func likePost(context *gin.Context) {
+func (h *handler) likePost(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	_, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
 	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
-	result, err := post.Like(userId)
+	result, err := h.store.Posts.LikePost(context, params.PostId, userId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to like post"})
+		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Post liked", "post": result})
+	h.updateUserPosts(context, result)
+	h.publish(events.PostLiked, result, result.Participants(), gin.H{"post_id": result.ID, "user": userId, "likes": len(result.Likes)})
+	h.notifyLike(context, result, userId)
+	context.JSON(http.StatusOK, gin.H{"message": "Post liked", "post": views.Post(result, h.viewer(context))})
 }
 
-func postComment(context *gin.Context) {
+func (h *handler) postComment(context *gin.Context) {
 	var newComment models.Comment
 	err := context.ShouldBindJSON(&newComment)
 	if err != nil {
@@ -186,23 +304,25 @@ func postComment(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	_, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
 	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
 	newComment.Creator = userId
-	result, err := post.AddComment(newComment)
+	result, err := h.store.Posts.AddComment(context, params.PostId, newComment)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to add comment", "error": err})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusCreated, gin.H{"message": "Comment added", "post": result})
+	h.updateUserPosts(context, result)
+	comment := result.Comments[len(result.Comments)-1]
+	h.publish(events.CommentCreated, result, result.Participants(), gin.H{"post_id": result.ID, "comment": comment})
+	h.notify(context, models.Notification{UserID: result.Creator, Type: models.NotificationPostCommented, ActorID: userId, PostID: result.ID, CommentID: comment.ID, Message: "New comment on your post " + result.Title})
+	context.JSON(http.StatusCreated, gin.H{"message": "Comment added", "post": views.Post(result, h.viewer(context))})
 }
 
-func likeComment(context *gin.Context) {
+func (h *handler) likeComment(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
@@ -213,21 +333,20 @@ func likeComment(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	_, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
-	result, err := post.LikeComment(params.CommentId, userId)
+	result, err := h.store.Posts.LikeComment(context, params.PostId, params.CommentId, userId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch post"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Comment liked", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Comment liked", "post": views.Post(result, h.viewer(context))})
 }
 
-func editComment(context *gin.Context) {
+func (h *handler) editComment(context *gin.Context) {
 	var updatedComment models.Comment
 	err := context.ShouldBindJSON(&updatedComment)
 	if err != nil {
@@ -239,22 +358,29 @@ func editComment(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	comment, ok := post.FindComment(params.CommentId)
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find comment"})
+		return
+	}
+	if !h.authorizeOwner(context, comment.Creator) {
 		return
 	}
 	updatedComment.ID = params.CommentId
-	result, err := post.UpdateComment(updatedComment)
+	result, err := h.store.Posts.UpdateComment(context, params.PostId, updatedComment)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update comment"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Updated comment", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Updated comment", "post": views.Post(result, h.viewer(context))})
 }
 
-func postReply(context *gin.Context) {
+func (h *handler) postReply(context *gin.Context) {
 	var reply models.Reply
 	err := context.ShouldBindJSON(&reply)
 	if err != nil {
@@ -271,22 +397,25 @@ func postReply(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	_, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
 	reply.Creator = userId
-	result, err := post.ReplyToComment(params.CommentId, reply)
+	result, err := h.store.Posts.ReplyToComment(context, params.PostId, params.CommentId, reply)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not reply to comment"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Reply posted", "post": result})
+	h.updateUserPosts(context, result)
+	if comment, ok := result.FindComment(params.CommentId); ok {
+		h.publish(events.ReplyCreated, result, result.Participants(), gin.H{"post_id": result.ID, "comment_id": comment.ID, "reply": comment.Replies[len(comment.Replies)-1]})
+		h.notify(context, models.Notification{UserID: comment.Creator, Type: models.NotificationCommentReplied, ActorID: userId, PostID: result.ID, CommentID: comment.ID, Message: "New reply to your comment on " + result.Title})
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Reply posted", "post": views.Post(result, h.viewer(context))})
 }
 
-func editReply(context *gin.Context) {
+func (h *handler) editReply(context *gin.Context) {
 	var updatedReply models.Reply
 	err := context.ShouldBindJSON(&updatedReply)
 	if err != nil {
@@ -298,22 +427,29 @@ func editReply(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	reply, ok := post.FindReply(params.CommentId, params.replyId)
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find reply"})
+		return
+	}
+	if !h.authorizeOwner(context, reply.Creator) {
 		return
 	}
 	updatedReply.ID = params.replyId
-	result, err := post.EditReply(params.CommentId, updatedReply)
+	result, err := h.store.Posts.EditReply(context, params.PostId, params.CommentId, updatedReply)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not edit reply"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Editted reply", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Editted reply", "post": views.Post(result, h.viewer(context))})
 }
 
-func likeReply(context *gin.Context) {
+func (h *handler) likeReply(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
@@ -324,56 +460,102 @@ func likeReply(context *gin.Context) {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not read user id header"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	_, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
-	result, err := post.LikeReply(params.CommentId, userId, params.replyId)
+	result, err := h.store.Posts.LikeReply(context, params.PostId, params.CommentId, params.replyId, userId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not like reply"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Liked reply", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Liked reply", "post": views.Post(result, h.viewer(context))})
 }
 
-func deleteReply(context *gin.Context) {
+func (h *handler) deleteReply(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
-	result, err := post.DeleteReply(params.CommentId, params.replyId)
+	reply, ok := post.FindReply(params.CommentId, params.replyId)
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find reply"})
+		return
+	}
+	if !h.authorizeOwner(context, reply.Creator) {
+		return
+	}
+	result, err := h.store.Posts.DeleteReply(context, params.PostId, params.CommentId, params.replyId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete reply"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Deleted reply", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Deleted reply", "post": views.Post(result, h.viewer(context))})
 }
 
-func deleteComment(context *gin.Context) {
+func (h *handler) deleteComment(context *gin.Context) {
 	params, err := getIdsFromParams(context)
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
 		return
 	}
-	post, err := models.FindPost(params.PostId)
-	if err != nil {
-		context.JSON(http.StatusNotFound, gin.H{"message": "Could not fetch post"})
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
 		return
 	}
-	result, err := post.DeleteComment(params.CommentId)
+	comment, ok := post.FindComment(params.CommentId)
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find comment"})
+		return
+	}
+	if !h.authorizeOwner(context, comment.Creator) {
+		return
+	}
+	result, err := h.store.Posts.DeleteComment(context, params.PostId, params.CommentId)
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete comment"})
 		return
 	}
-	updateUserPosts(context, result)
-	context.JSON(http.StatusOK, gin.H{"message": "Deleted comment", "post": result})
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Deleted comment", "post": views.Post(result, h.viewer(context))})
+}
+
+func (h *handler) changePostStatus(context *gin.Context) {
+	var request statusRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil || !models.IsReportStatus(request.Status) {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Status must be one of open, reunited, closed or expired"})
+		return
+	}
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	if !h.authorizeOwner(context, post.Creator) {
+		return
+	}
+	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	result, err := h.store.Posts.SetPostStatus(context, post.ID, request.Status, userId)
+	if err == models.ErrInvalidTransition {
+		context.JSON(http.StatusConflict, gin.H{"message": "Cannot change status", "kind": post.Kind, "from": post.Status, "to": request.Status})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not change status"})
+		return
+	}
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Status updated", "post": views.Post(result, h.viewer(context))})
 }
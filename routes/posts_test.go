@@ -0,0 +1,218 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"pet-search-backend-go/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// postFixture is an open lost report by owner carrying one comment with one
+// reply, both also by owner, so every mutating route has something to act on.
+func (ts *testServer) postFixture(owner models.User) models.Post {
+	ts.t.Helper()
+	ctx := context.Background()
+	post, err := ts.store.Posts.CreatePost(ctx, models.Post{Title: "Fixture", Content: "Fixture post", Kind: models.KindLost, Creator: owner.ID})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	post, err = ts.store.Posts.AddComment(ctx, post.ID, models.Comment{Creator: owner.ID, Content: "Fixture comment"})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	post, err = ts.store.Posts.ReplyToComment(ctx, post.ID, post.Comments[0].ID, models.Reply{Creator: owner.ID, Content: "Fixture reply"})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if err := ts.store.Users.AddUserPost(ctx, owner.ID, post); err != nil {
+		ts.t.Fatal(err)
+	}
+	return post
+}
+
+func TestPostMutationAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	owner, ownerToken := ts.signUp("owner", models.RoleUser)
+	_, otherToken := ts.signUp("other", models.RoleUser)
+	_, moderatorToken := ts.signUp("moderator", models.RoleModerator)
+
+	type ids struct{ post, comment, reply string }
+	type status struct{ owner, other, moderator, badId, missing int }
+	routes := []struct {
+		name   string
+		method string
+		path   func(ids) string
+		body   any
+		want   status
+	}{
+		{
+			"editPost", http.MethodPatch,
+			func(id ids) string { return "/feed/posts/" + id.post },
+			map[string]string{"title": "Edited"},
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"deletePost", http.MethodDelete,
+			func(id ids) string { return "/feed/posts/" + id.post },
+			nil,
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"changePostStatus", http.MethodPatch,
+			func(id ids) string { return "/feed/posts/" + id.post + "/status" },
+			map[string]string{"status": models.StatusClosed},
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"likePost", http.MethodPost,
+			func(id ids) string { return "/feed/posts/" + id.post + "/like" },
+			nil,
+			status{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"postComment", http.MethodPost,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment" },
+			map[string]string{"content": "New comment"},
+			status{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"editComment", http.MethodPatch,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment },
+			map[string]string{"content": "Edited"},
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"deleteComment", http.MethodDelete,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment },
+			nil,
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"likeComment", http.MethodPost,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment + "/like" },
+			nil,
+			status{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"postReply", http.MethodPost,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment + "/reply" },
+			map[string]string{"content": "New reply"},
+			status{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"editReply", http.MethodPatch,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment + "/reply/" + id.reply },
+			map[string]string{"content": "Edited"},
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"deleteReply", http.MethodDelete,
+			func(id ids) string { return "/feed/posts/" + id.post + "/comment/" + id.comment + "/reply/" + id.reply },
+			nil,
+			status{http.StatusOK, http.StatusForbidden, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+		{
+			"likeReply", http.MethodPost,
+			func(id ids) string {
+				return "/feed/posts/" + id.post + "/comment/" + id.comment + "/reply/" + id.reply + "/like"
+			},
+			nil,
+			status{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusBadRequest, http.StatusNotFound},
+		},
+	}
+
+	for _, route := range routes {
+		cases := []struct {
+			name  string
+			token string
+			post  func(models.Post) string
+			want  int
+		}{
+			{"owner", ownerToken, func(p models.Post) string { return p.ID.Hex() }, route.want.owner},
+			{"non-owner", otherToken, func(p models.Post) string { return p.ID.Hex() }, route.want.other},
+			{"moderator", moderatorToken, func(p models.Post) string { return p.ID.Hex() }, route.want.moderator},
+			{"bad ObjectID", ownerToken, func(models.Post) string { return "not-an-id" }, route.want.badId},
+			{"missing post", ownerToken, func(models.Post) string { return primitive.NewObjectID().Hex() }, route.want.missing},
+		}
+		for _, c := range cases {
+			t.Run(route.name+"/"+c.name, func(t *testing.T) {
+				post := ts.postFixture(owner)
+				comment := post.Comments[0]
+				path := route.path(ids{post: c.post(post), comment: comment.ID.Hex(), reply: comment.Replies[0].ID.Hex()})
+				recorder := ts.do(route.method, path, c.token, route.body)
+				expectStatus(t, recorder, c.want)
+				if c.want < http.StatusBadRequest {
+					return
+				}
+				stored, err := ts.store.Posts.FindPost(context.Background(), post.ID)
+				if err != nil {
+					t.Fatalf("post is gone after a rejected request: %v", err)
+				}
+				if !reflect.DeepEqual(stored, post) {
+					t.Errorf("post changed after a rejected request:\n got %+v\nwant %+v", stored, post)
+				}
+			})
+		}
+	}
+}
+
+func TestCreatePostSetsCreator(t *testing.T) {
+	ts := newTestServer(t)
+	owner, _ := ts.signUp("owner", models.RoleUser)
+	other, otherToken := ts.signUp("other", models.RoleUser)
+
+	recorder := ts.do(http.MethodPost, "/feed/posts/", otherToken, map[string]any{"title": "New post", "content": "Hello", "creator": owner.ID.Hex()})
+	expectStatus(t, recorder, http.StatusCreated)
+	var response struct {
+		Post models.Post `json:"post"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Post.Creator != other.ID {
+		t.Errorf("creator = %s, want the caller %s", response.Post.Creator.Hex(), other.ID.Hex())
+	}
+}
+
+func TestCreatePostAuthorization(t *testing.T) {
+	ts := newTestServer(t)
+	owner, ownerToken := ts.signUp("owner", models.RoleUser)
+	_, otherToken := ts.signUp("other", models.RoleUser)
+	_, moderatorToken := ts.signUp("moderator", models.RoleModerator)
+	group, err := ts.store.Groups.CreateGroup(context.Background(), models.Group{
+		GroupName: "Neighbours",
+		Members:   []models.Member{{UserID: owner.ID, Role: models.GroupRoleOwner, JoinedAt: time.Now()}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		groups any
+		want   int
+	}{
+		{"no group", otherToken, nil, http.StatusCreated},
+		{"group owner", ownerToken, []string{group.ID.Hex()}, http.StatusCreated},
+		{"non-member", otherToken, []string{group.ID.Hex()}, http.StatusForbidden},
+		{"moderator non-member", moderatorToken, []string{group.ID.Hex()}, http.StatusForbidden},
+		{"bad ObjectID", ownerToken, []string{"not-an-id"}, http.StatusBadRequest},
+		{"missing group", ownerToken, []string{primitive.NewObjectID().Hex()}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := map[string]any{"title": "New post", "content": "Hello"}
+			if test.groups != nil {
+				body["groups"] = test.groups
+			}
+			recorder := ts.do(http.MethodPost, "/feed/posts/", test.token, body)
+			expectStatus(t, recorder, test.want)
+		})
+	}
+}
@@ -1,50 +1,159 @@
 package routes
 
 import (
+	"pet-search-backend-go/config"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/lockout"
+	"pet-search-backend-go/mail"
 	"pet-search-backend-go/middleware"
+	"pet-search-backend-go/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(server *gin.Engine) {
+type handler struct {
+	store           models.Store
+	hub             events.Hub
+	presence        *presence
+	mailer          mail.Mailer
+	accountAttempts *lockout.Limiter
+	addressAttempts *lockout.Limiter
+	resetAddresses  *lockout.Limiter
+	cfg             config.Config
+}
+
+func RegisterRoutes(server *gin.Engine, store models.Store, hub events.Hub, mailer mail.Mailer, attempts lockout.Store, cfg config.Config) {
+	h := &handler{
+		store:           store,
+		hub:             hub,
+		presence:        newPresence(),
+		mailer:          mailer,
+		accountAttempts: lockout.NewLimiter(attempts, "account", lockout.AccountPolicy),
+		addressAttempts: lockout.NewLimiter(attempts, "ip", lockout.IPPolicy),
+		resetAddresses:  lockout.NewLimiter(attempts, "reset-ip", lockout.MailPolicy),
+		cfg:             cfg,
+	}
+	authenticate := middleware.Authenticate(cfg.JWT.Secret, store.Sessions, store.AccountTokens)
+
 	// Posts
-	postFeed := server.Group("/feed/posts").Use(middleware.Authenticate)
+	postFeed := server.Group("/feed/posts").Use(authenticate)
 	{
-		postFeed.GET("/", getPosts)
-		postFeed.POST("/", createPost)
-		postFeed.GET("/:postId", getPost)
-		postFeed.PATCH("/:postId", editPost)
-		postFeed.DELETE("/:postId", deletePost)
-		postFeed.POST("/:postId/like", likePost)
-		postFeed.POST("/:postId/comment", postComment)
-		postFeed.PATCH("/:postId/comment/:commentId", editComment)
-		postFeed.DELETE("/:postId/comment/:commentId", deleteComment)
-		postFeed.POST("/:postId/comment/:commentId/like", likeComment)
-		postFeed.POST("/:postId/comment/:commentId/reply", postReply)
-		postFeed.PATCH("/:postId/comment/:commentId/reply/:replyId", editReply)
-		postFeed.DELETE("/:postId/comment/:commentId/reply/:replyId", deleteReply)
-		postFeed.POST("/:postId/comment/:commentId/reply/:replyId/like", likeReply)
+		postFeed.GET("/", h.getPosts)
+		postFeed.POST("/", h.createPost)
+		postFeed.GET("/nearby", h.getNearbyPosts)
+		postFeed.GET("/within", h.getPostsWithin)
+		postFeed.GET("/:postId", h.getPost)
+		postFeed.PATCH("/:postId", h.editPost)
+		postFeed.DELETE("/:postId", h.deletePost)
+		postFeed.PATCH("/:postId/status", h.changePostStatus)
+		postFeed.GET("/:postId/live", h.livePost)
+		postFeed.POST("/:postId/conversations", h.startConversation)
+		postFeed.GET("/:postId/matches", h.getPostMatches)
+		postFeed.POST("/:postId/matches/:matchId/confirm", h.decideMatch(models.MatchConfirmed))
+		postFeed.POST("/:postId/matches/:matchId/dismiss", h.decideMatch(models.MatchDismissed))
+		postFeed.GET("/:postId/sightings", h.getSightings)
+		postFeed.POST("/:postId/sightings", h.postSighting)
+		postFeed.DELETE("/:postId/sightings/:sightingId", h.deleteSighting)
+		postFeed.POST("/:postId/like", h.likePost)
+		postFeed.POST("/:postId/comment", h.postComment)
+		postFeed.PATCH("/:postId/comment/:commentId", h.editComment)
+		postFeed.DELETE("/:postId/comment/:commentId", h.deleteComment)
+		postFeed.POST("/:postId/comment/:commentId/like", h.likeComment)
+		postFeed.POST("/:postId/comment/:commentId/reply", h.postReply)
+		postFeed.PATCH("/:postId/comment/:commentId/reply/:replyId", h.editReply)
+		postFeed.DELETE("/:postId/comment/:commentId/reply/:replyId", h.deleteReply)
+		postFeed.POST("/:postId/comment/:commentId/reply/:replyId/like", h.likeReply)
 	}
 
 	// Auth
 	auth := server.Group("/auth")
 	{
-		auth.POST("/signup", signup)
-		auth.POST("/login", login)
+		auth.POST("/signup", h.signup)
+		auth.POST("/login", h.login)
+		auth.POST("/refresh", h.refresh)
+		auth.POST("/forgot-password", h.forgotPassword)
+		auth.POST("/reset-password", h.resetPassword)
+		auth.POST("/verify", h.verifyEmail)
+		auth.POST("/verify/resend", authenticate, h.resendVerification)
+		auth.POST("/unlock", h.unlockAccount)
+		auth.POST("/2fa/verify", h.verifyLogin)
+		auth.POST("/2fa/setup", h.setupLogin)
+		auth.POST("/2fa/setup/confirm", h.confirmSetupLogin)
+		auth.POST("/2fa/enroll", authenticate, h.enrollTwoFactor)
+		auth.POST("/2fa/confirm", authenticate, h.confirmTwoFactor)
+		auth.POST("/2fa/disable", authenticate, h.disableTwoFactor)
+		auth.POST("/2fa/backup-codes", authenticate, h.regenerateBackupCodes)
+		auth.POST("/logout", authenticate, h.logout)
+		auth.POST("/logout-all", authenticate, h.logoutAll)
+		auth.GET("/sessions", authenticate, h.getSessions)
+		auth.DELETE("/sessions/:sessionId", authenticate, h.revokeSession)
+		auth.POST("/stream-ticket", authenticate, h.createStreamTicket)
 	}
 
+	// Watch area subscriptions
+	subscriptions := server.Group("/subscriptions").Use(authenticate)
+	{
+		subscriptions.GET("/", h.getSubscriptions)
+		subscriptions.POST("/", h.createSubscription)
+		subscriptions.GET("/:subscriptionId", h.getSubscription)
+		subscriptions.PATCH("/:subscriptionId", h.editSubscription)
+		subscriptions.DELETE("/:subscriptionId", h.deleteSubscription)
+	}
+
+	// Notifications
+	notifications := server.Group("/notifications").Use(authenticate)
+	{
+		notifications.GET("/", h.getNotifications)
+		notifications.GET("/unread-count", h.getUnreadCount)
+		notifications.POST("/read-all", h.markAllNotificationsRead)
+		notifications.POST("/:notificationId/read", h.markNotificationRead)
+		notifications.GET("/preferences", h.getNotificationPreferences)
+		notifications.PUT("/preferences", h.updateNotificationPreferences)
+	}
+
+	// Private conversations
+	conversations := server.Group("/conversations").Use(authenticate)
+	{
+		conversations.GET("/", h.getConversations)
+		conversations.GET("/:conversationId", h.getConversation)
+		conversations.POST("/:conversationId/messages", h.sendMessage)
+		conversations.POST("/:conversationId/read", h.markConversationRead)
+		conversations.POST("/:conversationId/contact", h.shareContact)
+		conversations.GET("/:conversationId/contact", h.getContact)
+		conversations.POST("/:conversationId/block", h.blockParticipant(true))
+		conversations.POST("/:conversationId/unblock", h.blockParticipant(false))
+	}
+
+	// Audit log
+	server.GET("/audit-events", authenticate, h.getAuditEvents)
+
+	// Real-time events
+	server.GET("/stream", authenticate, h.stream)
+
 	// User
-	user := server.Group("/users").Use(middleware.Authenticate)
+	user := server.Group("/users").Use(authenticate)
 	{
-		user.GET("/", getUsers)
-		user.GET("/:userId", getUser)
+		user.GET("/", h.getUsers)
+		user.GET("/:userId", h.getUser)
+		user.PUT("/:userId/two-factor", h.requireTwoFactor)
 	}
 
 	// Groups
-	groups := server.Group("/groups").Use(middleware.Authenticate)
+	groups := server.Group("/groups").Use(authenticate)
 	{
-		groups.GET("/")
-		groups.GET("/:groupId")
-		groups.POST("/:groupId")
+		groups.GET("/", h.getGroups)
+		groups.POST("/", h.createGroup)
+		groups.GET("/:groupId", h.getGroup)
+		groups.PATCH("/:groupId", h.editGroup)
+		groups.DELETE("/:groupId", h.deleteGroup)
+		groups.GET("/:groupId/posts", h.getGroupPosts)
+		groups.POST("/:groupId/join", h.joinGroup)
+		groups.POST("/:groupId/leave", h.leaveGroup)
+		groups.POST("/:groupId/invitations", h.inviteMember)
+		groups.DELETE("/:groupId/invitations/:userId", h.cancelInvitation)
+		groups.POST("/:groupId/requests/:userId/approve", h.approveJoinRequest)
+		groups.POST("/:groupId/requests/:userId/deny", h.denyJoinRequest)
+		groups.PATCH("/:groupId/members/:userId", h.changeMemberRole)
+		groups.DELETE("/:groupId/members/:userId", h.removeMember)
 	}
 }
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"pet-search-backend-go/config"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/lockout"
+	"pet-search-backend-go/mail"
+	"pet-search-backend-go/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testPassword = "Correct-horse9"
+
+// roleStore hands out roles the API has no endpoint for yet.
+type roleStore struct {
+	models.UserStore
+	roles map[primitive.ObjectID]string
+}
+
+func (s roleStore) FindUserByID(ctx context.Context, userId primitive.ObjectID) (models.User, error) {
+	user, err := s.UserStore.FindUserByID(ctx, userId)
+	if role, ok := s.roles[userId]; ok {
+		user.Role = role
+	}
+	return user, err
+}
+
+// discardMailer drops every email. Handlers send mail in the background, so
+// writing it anywhere would race with test cleanup.
+type discardMailer struct{}
+
+func (discardMailer) Send(ctx context.Context, message mail.Message) error { return nil }
+
+// testServer is the API over the in-memory store.
+type testServer struct {
+	t      *testing.T
+	h      *handler
+	server *gin.Engine
+	store  models.Store
+	roles  map[primitive.ObjectID]string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := config.Default()
+	cfg.JWT.Secret = strings.Repeat("s", 32)
+	roles := map[primitive.ObjectID]string{}
+	store := models.NewMemoryStore()
+	store.Users = roleStore{UserStore: store.Users, roles: roles}
+	hub := events.NewMemoryHub()
+	t.Cleanup(hub.Close)
+	server := gin.New()
+	RegisterRoutes(server, store, hub, discardMailer{}, lockout.NewMemoryStore(), cfg)
+	return &testServer{t: t, h: &handler{store: store, cfg: cfg}, server: server, store: store, roles: roles}
+}
+
+// signUp adds a verified user with role and signs them in.
+func (ts *testServer) signUp(username, role string) (models.User, string) {
+	ts.t.Helper()
+	ctx := context.Background()
+	user, err := ts.store.Users.AddUser(ctx, models.User{Username: username, Email: username + "@example.com", Password: testPassword})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	if err := ts.store.Users.SetEmailVerified(ctx, user.ID); err != nil {
+		ts.t.Fatal(err)
+	}
+	ts.roles[user.ID] = role
+	session, err := ts.store.Sessions.CreateSession(ctx, models.Session{UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	token, err := ts.h.createToken(session)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	user, err = ts.store.Users.FindUserByID(ctx, user.ID)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return user, token
+}
+
+func (ts *testServer) do(method, path, token string, body any) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			ts.t.Fatal(err)
+		}
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(data))
+	request.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	ts.server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func expectStatus(t *testing.T, recorder *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if recorder.Code != want {
+		t.Fatalf("status = %d, want %d; body %s", recorder.Code, want, recorder.Body)
+	}
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *handler) getSessions(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sessionId, ok := currentSessionId(context)
+	if !ok {
+		return
+	}
+	sessions, err := h.store.Sessions.FindUserSessions(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch sessions"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"sessions": views.Sessions(sessions, sessionId)})
+}
+
+// revokeSession signs one of the caller's devices out. Its access token stops
+// working on the next request and its refresh token can no longer be used.
+func (h *handler) revokeSession(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sessionId, err := primitive.ObjectIDFromHex(context.Param("sessionId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	err = h.store.Sessions.RevokeSession(context, userId, sessionId)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find session"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not revoke session"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
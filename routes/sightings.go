@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (h *handler) postSighting(context *gin.Context) {
+	var sighting models.Sighting
+	err := context.ShouldBindJSON(&sighting)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	if post.Kind != models.KindLost {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Sightings can only be added to lost reports"})
+		return
+	}
+	if post.Status != models.StatusOpen {
+		context.JSON(http.StatusConflict, gin.H{"message": "This report is no longer open", "status": post.Status})
+		return
+	}
+	sighting.Normalize()
+	if err := sighting.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid sighting", "error": err.Error()})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sighting.Creator = userId
+	result, err := h.store.Posts.AddSighting(context, post.ID, sighting)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to add sighting"})
+		return
+	}
+	h.updateUserPosts(context, result)
+	h.publish(events.SightingCreated, result, result.Participants(), gin.H{"post_id": result.ID, "sighting": result.Sightings[len(result.Sightings)-1]})
+	context.JSON(http.StatusCreated, gin.H{"message": "Sighting added", "post": views.Post(result, h.viewer(context))})
+}
+
+func (h *handler) getSightings(context *gin.Context) {
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, post.SightingTrail())
+}
+
+func (h *handler) deleteSighting(context *gin.Context) {
+	params, err := getIdsFromParams(context)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	sightingId, err := primitive.ObjectIDFromHex(context.Param("sightingId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	post, ok := h.findVisiblePost(context, params.PostId)
+	if !ok {
+		return
+	}
+	sighting, ok := post.FindSighting(sightingId)
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find sighting"})
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	// The report owner may remove sightings on their report, not just their own.
+	if sighting.Creator != userId && !h.authorizeOwner(context, post.Creator) {
+		return
+	}
+	result, err := h.store.Posts.DeleteSighting(context, post.ID, sightingId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to delete sighting"})
+		return
+	}
+	h.updateUserPosts(context, result)
+	context.JSON(http.StatusOK, gin.H{"message": "Sighting deleted", "post": views.Post(result, h.viewer(context))})
+}
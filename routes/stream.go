@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"pet-search-backend-go/events"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	streamHeartbeat = 25 * time.Second
+	// streamVisibilityRefresh is how long a stream goes on using the role
+	// and group memberships it looked up, before fetching them again.
+	streamVisibilityRefresh = time.Minute
+)
+
+// publish hands the event to the hub; post is used for visibility checks
+// when the event is delivered.
+func (h *handler) publish(kind string, post models.Post, audience []primitive.ObjectID, data any) {
+	h.hub.Publish(events.Event{Type: kind, Audience: audience, Post: &post, Data: data})
+}
+
+// streamable reports whether the event should reach this user: it must be
+// addressed to them and its post, if any, visible to them.
+func streamable(visibility *visibility, userId primitive.ObjectID, event events.Event) bool {
+	if event.Transient || !event.Addressed(userId) {
+		return false
+	}
+	if event.Post == nil {
+		return true
+	}
+	ok, err := visibility.allows(*event.Post)
+	return err == nil && ok
+}
+
+// createStreamTicket issues a single-use ticket for opening /stream or a
+// post's live socket from a browser, which cannot send the Authorization
+// header there. It is passed as the ticket query parameter within
+// StreamTicketTTL and is bound to the caller's session.
+func (h *handler) createStreamTicket(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	sessionId, ok := currentSessionId(context)
+	if !ok {
+		return
+	}
+	ticket, hash, err := models.NewToken()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create stream ticket"})
+		return
+	}
+	_, err = h.store.AccountTokens.CreateAccountToken(context, models.AccountToken{UserID: userId, SessionID: sessionId, Purpose: models.TokenStreamTicket, Hash: hash, ExpiresAt: time.Now().Add(models.StreamTicketTTL)})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create stream ticket"})
+		return
+	}
+	context.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_in": int(models.StreamTicketTTL.Seconds())})
+}
+
+// stream pushes new posts, comments and replies on posts the user takes part
+// in, and the user's notifications, as Server-Sent Events. Clients resume
+// with the Last-Event-ID header (or last_event_id query parameter, for
+// EventSource implementations that cannot set headers).
+func (h *handler) stream(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	lastEventId := context.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = context.Query("last_event_id")
+	}
+	var lastId uint64
+	if lastEventId != "" {
+		var err error
+		lastId, err = strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			context.JSON(http.StatusBadRequest, gin.H{"message": "Last-Event-ID must be an event id"})
+			return
+		}
+	}
+
+	subscription := h.hub.Subscribe(lastId)
+	defer subscription.Cancel()
+	// The server's write timeout is meant for ordinary requests.
+	_ = http.NewResponseController(context.Writer).SetWriteDeadline(time.Time{})
+	context.Header("Cache-Control", "no-cache")
+	context.Header("Connection", "keep-alive")
+	context.Header("X-Accel-Buffering", "no")
+	context.Status(http.StatusOK)
+
+	viewer := h.viewer(context)
+	visibility := h.newVisibility(context)
+	send := func(event events.Event) {
+		if !streamable(visibility, userId, event) {
+			return
+		}
+		data := event.Data
+		if post, ok := data.(models.Post); ok {
+			data = views.Post(post, viewer)
+		}
+		context.Render(-1, sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: event.Type, Data: data})
+	}
+	if subscription.Reset {
+		context.Render(-1, sse.Event{Event: "reset", Data: gin.H{"message": "Some events were missed. Refetch the feed"}})
+	}
+	for _, event := range subscription.Replay {
+		send(event)
+	}
+	context.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	refresh := time.NewTicker(streamVisibilityRefresh)
+	defer refresh.Stop()
+	for {
+		select {
+		case <-context.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+			send(event)
+		case <-heartbeat.C:
+			_, _ = io.WriteString(context.Writer, ": heartbeat\n\n")
+		case <-refresh.C:
+			viewer, visibility = h.viewer(context), h.newVisibility(context)
+			continue
+		}
+		context.Writer.Flush()
+	}
+}
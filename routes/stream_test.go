@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pet-search-backend-go/models"
+	"testing"
+)
+
+func TestStreamTicketOnlyOpensStreams(t *testing.T) {
+	ts := newTestServer(t)
+	owner, ownerToken := ts.signUp("owner", models.RoleUser)
+	post := ts.postFixture(owner)
+	recorder := ts.do(http.MethodPost, "/auth/stream-ticket", ownerToken, nil)
+	expectStatus(t, recorder, http.StatusCreated)
+	var response struct {
+		Ticket string `json:"ticket"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/feed/posts/"},
+		{http.MethodPatch, "/feed/posts/" + post.ID.Hex()},
+		{http.MethodDelete, "/feed/posts/" + post.ID.Hex()},
+		{http.MethodPost, "/feed/posts/" + post.ID.Hex() + "/comment"},
+		{http.MethodGet, "/users/me"},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			request := httptest.NewRequest(test.method, test.path+"?ticket="+response.Ticket, bytes.NewReader([]byte(`{"title":"Stolen","content":"Stolen"}`)))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Accept", "text/event-stream")
+			request.Header.Set("Upgrade", "websocket")
+			recorder := httptest.NewRecorder()
+			ts.server.ServeHTTP(recorder, request)
+			expectStatus(t, recorder, http.StatusUnauthorized)
+		})
+	}
+	if _, err := ts.store.AccountTokens.ConsumeAccountToken(context.Background(), models.TokenStreamTicket, models.HashToken(response.Ticket)); err != nil {
+		t.Errorf("ticket was spent by a non-stream route: %v", err)
+	}
+}
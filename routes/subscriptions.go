@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"pet-search-backend-go/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// findOwnSubscription loads the subscription in the path and hides other
+// users' watch areas behind a 404.
+func (h *handler) findOwnSubscription(context *gin.Context) (models.Subscription, bool) {
+	subscriptionId, err := primitive.ObjectIDFromHex(context.Param("subscriptionId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Subscription{}, false
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return models.Subscription{}, false
+	}
+	subscription, err := h.store.Subscriptions.FindSubscription(context, subscriptionId)
+	if err == models.ErrNotFound || (err == nil && subscription.UserID != userId) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find subscription"})
+		return models.Subscription{}, false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch subscription"})
+		return models.Subscription{}, false
+	}
+	return subscription, true
+}
+
+func bindSubscription(context *gin.Context) (models.Subscription, bool) {
+	var subscription models.Subscription
+	if err := context.ShouldBindJSON(&subscription); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return models.Subscription{}, false
+	}
+	subscription.Normalize()
+	if err := subscription.Validate(); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid watch area", "error": err.Error()})
+		return models.Subscription{}, false
+	}
+	return subscription, true
+}
+
+func (h *handler) getSubscriptions(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	subscriptions, err := h.store.Subscriptions.FindSubscriptions(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch subscriptions. Try again later"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+func (h *handler) getSubscription(context *gin.Context) {
+	subscription, ok := h.findOwnSubscription(context)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"subscription": subscription})
+}
+
+func (h *handler) createSubscription(context *gin.Context) {
+	subscription, ok := bindSubscription(context)
+	if !ok {
+		return
+	}
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	existing, err := h.store.Subscriptions.FindSubscriptions(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create subscription"})
+		return
+	}
+	if len(existing) >= models.MaxSubscriptions {
+		context.JSON(http.StatusConflict, gin.H{"message": "You already have the maximum number of watch areas"})
+		return
+	}
+	subscription.UserID = userId
+	result, err := h.store.Subscriptions.CreateSubscription(context, subscription)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create subscription"})
+		return
+	}
+	context.JSON(http.StatusCreated, gin.H{"message": "Subscription created", "subscription": result})
+}
+
+func (h *handler) editSubscription(context *gin.Context) {
+	updated, ok := bindSubscription(context)
+	if !ok {
+		return
+	}
+	subscription, ok := h.findOwnSubscription(context)
+	if !ok {
+		return
+	}
+	updated.ID = subscription.ID
+	result, err := h.store.Subscriptions.UpdateSubscription(context, updated)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not update subscription"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Subscription updated", "subscription": result})
+}
+
+func (h *handler) deleteSubscription(context *gin.Context) {
+	subscription, ok := h.findOwnSubscription(context)
+	if !ok {
+		return
+	}
+	if err := h.store.Subscriptions.DeleteSubscription(context, subscription.ID); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not delete subscription"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Subscription deleted", "subscriptionId": subscription.ID})
+}
+
+// notifyWatchers tells everyone watching the report's location about it,
+// once per user, skipping the author and anyone who cannot see the post.
+func (h *handler) notifyWatchers(context *gin.Context, post models.Post) {
+	watchers, err := h.store.Subscriptions.FindWatchers(context, post)
+	if err != nil {
+		log.Printf("Could not find watchers for post %s: %v", post.ID.Hex(), err)
+		return
+	}
+	var groups []models.Group
+	for _, groupId := range post.Groups {
+		if group, err := h.store.Groups.FindGroup(context, groupId); err == nil {
+			groups = append(groups, group)
+		}
+	}
+	notified := map[primitive.ObjectID]bool{post.Creator: true}
+	var notifications []models.Notification
+	for _, watcher := range watchers {
+		if notified[watcher.UserID] || !post.VisibleTo(watcher.UserID, groups) {
+			continue
+		}
+		notified[watcher.UserID] = true
+		notifications = append(notifications, models.Notification{
+			UserID:         watcher.UserID,
+			Type:           models.NotificationNearbyReport,
+			PostID:         post.ID,
+			SubscriptionID: watcher.ID,
+			Message:        "New " + post.Kind + " report in " + watcher.Name,
+		})
+	}
+	h.notify(context, notifications...)
+}
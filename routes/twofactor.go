@@ -0,0 +1,428 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"pet-search-backend-go/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// challengeTTL is how long a password-checked login waits for its second
+	// step.
+	challengeTTL = 5 * time.Minute
+	totpIssuer   = "Pet Search"
+
+	// A login challenge asks for a code; a setup challenge lets a user an
+	// admin requires 2FA of enroll before their first session.
+	challengeLogin = "2fa_login"
+	challengeSetup = "2fa_setup"
+)
+
+type challenge struct {
+	UserID     primitive.ObjectID
+	Purpose    string
+	DeviceName string
+}
+
+type secondFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	BackupCode     string `json:"backup_code"`
+}
+
+type twoFactorRequirement struct {
+	Required *bool `json:"required" binding:"required"`
+}
+
+// createChallenge signs a short-lived token that stands in for the password
+// between the two login steps. It carries no session, so Authenticate never
+// accepts it as an access token.
+func (h *handler) createChallenge(user models.User, purpose, deviceName string) (string, error) {
+	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID,
+		"typ": purpose,
+		"dev": deviceName,
+		"iss": h.cfg.JWT.Issuer,
+		"exp": time.Now().Add(challengeTTL).Unix(),
+		"iat": time.Now().Unix(),
+	})
+	return claims.SignedString([]byte(h.cfg.JWT.Secret))
+}
+
+func (h *handler) parseChallenge(context *gin.Context, token string) (challenge, bool) {
+	invalid := func() (challenge, bool) {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Login challenge is invalid or has expired, please log in again"})
+		return challenge{}, false
+	}
+	decoded, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(h.cfg.JWT.Secret), nil
+	}, jwt.WithIssuer(h.cfg.JWT.Issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return invalid()
+	}
+	claims, ok := decoded.Claims.(jwt.MapClaims)
+	if !ok {
+		return invalid()
+	}
+	purpose, _ := claims["typ"].(string)
+	subject, _ := claims["sub"].(string)
+	deviceName, _ := claims["dev"].(string)
+	userId, err := primitive.ObjectIDFromHex(subject)
+	if err != nil || (purpose != challengeLogin && purpose != challengeSetup) {
+		return invalid()
+	}
+	return challenge{UserID: userId, Purpose: purpose, DeviceName: deviceName}, true
+}
+
+// challengeLoginStep answers a correct password for an account with 2FA, or
+// one required to have it, with a challenge instead of tokens.
+func (h *handler) challengeLoginStep(context *gin.Context, user models.User, deviceName string) {
+	purpose, message := challengeLogin, "Enter the code from your authenticator app"
+	if !user.TwoFactor.Enabled {
+		purpose, message = challengeSetup, "Two-factor authentication is required for this account, set it up to continue"
+	}
+	token, err := h.createChallenge(user, purpose, deviceName)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log in user"})
+		return
+	}
+	context.JSON(http.StatusAccepted, gin.H{"message": message, "two_factor": purpose, "challenge_token": token, "expires_in": int64(challengeTTL.Seconds())})
+}
+
+// checkSecondFactor accepts a current TOTP code or an unused backup code,
+// spending either so it cannot be replayed.
+func (h *handler) checkSecondFactor(context *gin.Context, user models.User, request secondFactorRequest) (bool, error) {
+	var err error
+	switch {
+	case request.Code != "":
+		step, ok := models.VerifyTOTP(user.TwoFactor.Secret, request.Code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		err = h.store.Users.UseTOTPStep(context, user.ID, step)
+	case request.BackupCode != "":
+		err = h.store.Users.UseBackupCode(context, user.ID, models.HashBackupCode(request.BackupCode))
+	default:
+		return false, nil
+	}
+	if err == models.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// verifySecondFactor checks the caller's code under the same throttling as
+// passwords, since six digits fall quickly to guessing. It responds itself
+// unless the code was right.
+func (h *handler) verifySecondFactor(context *gin.Context, user models.User, request secondFactorRequest) bool {
+	email := models.NormalizeEmail(user.Email)
+	attempt, ok := h.allowLogin(context, email)
+	if !ok {
+		return false
+	}
+	ok, err := h.checkSecondFactor(context, user, request)
+	if err != nil {
+		h.refundLogin(context, attempt)
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not check two-factor code"})
+		return false
+	}
+	if !ok {
+		h.loginFailed(context, attempt, &user)
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid two-factor code"})
+		return false
+	}
+	h.attemptSucceeded(context, attempt)
+	return true
+}
+
+func (h *handler) beginEnrollment(context *gin.Context, user models.User) {
+	if user.TwoFactor.Enabled {
+		context.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is already enabled"})
+		return
+	}
+	secret, err := models.NewTOTPSecret()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not start two-factor enrollment"})
+		return
+	}
+	if err := h.store.Users.StartTwoFactor(context, user.ID, secret); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not start two-factor enrollment"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{
+		"message":          "Add this account to your authenticator app, then confirm with a code from it",
+		"secret":           secret,
+		"provisioning_uri": models.ProvisioningURI(secret, totpIssuer, user.Email),
+	})
+}
+
+// completeEnrollment turns 2FA on once the user proves their app produces
+// codes for the pending secret, and returns the backup codes, which are only
+// ever shown here.
+func (h *handler) completeEnrollment(context *gin.Context, user models.User, code string) ([]string, bool) {
+	if user.TwoFactor.Enabled {
+		context.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is already enabled"})
+		return nil, false
+	}
+	if user.TwoFactor.Secret == "" {
+		context.JSON(http.StatusConflict, gin.H{"message": "Start two-factor enrollment first"})
+		return nil, false
+	}
+	step, ok := models.VerifyTOTP(user.TwoFactor.Secret, code, time.Now())
+	if !ok {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Invalid two-factor code"})
+		return nil, false
+	}
+	codes, hashes, err := models.NewBackupCodes()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not enable two-factor authentication"})
+		return nil, false
+	}
+	if err := h.store.Users.EnableTwoFactor(context, user.ID, hashes, step); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not enable two-factor authentication"})
+		return nil, false
+	}
+	h.audit(context, models.AuditEvent{Type: models.AuditTwoFactorOn, UserID: user.ID, Email: user.Email, IP: context.ClientIP()})
+	return codes, true
+}
+
+func bindSecondFactor(context *gin.Context) (secondFactorRequest, bool) {
+	var request secondFactorRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return secondFactorRequest{}, false
+	}
+	return request, true
+}
+
+// challengeUser resolves the challenge in request to its user and checks it
+// was issued for purpose.
+func (h *handler) challengeUser(context *gin.Context, request secondFactorRequest, purpose string) (challenge, models.User, bool) {
+	found, ok := h.parseChallenge(context, request.ChallengeToken)
+	if !ok {
+		return challenge{}, models.User{}, false
+	}
+	if found.Purpose != purpose {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Login challenge is for a different step"})
+		return challenge{}, models.User{}, false
+	}
+	user, err := h.store.Users.FindUserByID(context, found.UserID)
+	if err != nil {
+		context.JSON(http.StatusUnauthorized, gin.H{"message": "Login challenge is invalid or has expired, please log in again"})
+		return challenge{}, models.User{}, false
+	}
+	return found, user, true
+}
+
+func (h *handler) loginSucceeded(context *gin.Context, user models.User, deviceName string, extra gin.H) {
+	issued, err := h.startSession(context, user.ID, deviceName)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not log in user"})
+		return
+	}
+	response := gin.H{"message": "Login Successful", "token": issued.Token, "refresh_token": issued.RefreshToken, "expires_in": issued.ExpiresIn, "user": user.Email}
+	for key, value := range extra {
+		response[key] = value
+	}
+	context.JSON(http.StatusAccepted, response)
+}
+
+// verifyLogin is the second login step: a code for a login challenge.
+func (h *handler) verifyLogin(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	found, user, ok := h.challengeUser(context, request, challengeLogin)
+	if !ok {
+		return
+	}
+	if !user.TwoFactor.Enabled {
+		context.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is not enabled, please log in again"})
+		return
+	}
+	if !h.verifySecondFactor(context, user, request) {
+		return
+	}
+	h.loginSucceeded(context, user, found.DeviceName, nil)
+}
+
+// setupLogin lets a user who must have 2FA enroll with a setup challenge.
+func (h *handler) setupLogin(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	_, user, ok := h.challengeUser(context, request, challengeSetup)
+	if !ok {
+		return
+	}
+	h.beginEnrollment(context, user)
+}
+
+// confirmSetupLogin finishes enrollment started by setupLogin and logs the
+// user in.
+func (h *handler) confirmSetupLogin(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	found, user, ok := h.challengeUser(context, request, challengeSetup)
+	if !ok {
+		return
+	}
+	codes, ok := h.completeEnrollment(context, user, request.Code)
+	if !ok {
+		return
+	}
+	h.loginSucceeded(context, user, found.DeviceName, gin.H{"backup_codes": codes})
+}
+
+func (h *handler) currentUser(context *gin.Context) (models.User, bool) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return models.User{}, false
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not find user"})
+		return models.User{}, false
+	}
+	return user, true
+}
+
+func (h *handler) enrollTwoFactor(context *gin.Context) {
+	user, ok := h.currentUser(context)
+	if !ok {
+		return
+	}
+	h.beginEnrollment(context, user)
+}
+
+func (h *handler) confirmTwoFactor(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(context)
+	if !ok {
+		return
+	}
+	codes, ok := h.completeEnrollment(context, user, request.Code)
+	if !ok {
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled, keep these backup codes somewhere safe", "backup_codes": codes})
+}
+
+func (h *handler) disableTwoFactor(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(context)
+	if !ok {
+		return
+	}
+	if !user.TwoFactor.Enabled {
+		context.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is not enabled"})
+		return
+	}
+	if user.TwoFactorRequired {
+		context.JSON(http.StatusForbidden, gin.H{"message": "An admin requires two-factor authentication for this account"})
+		return
+	}
+	if !h.verifySecondFactor(context, user, request) {
+		return
+	}
+	if err := h.store.Users.DisableTwoFactor(context, user.ID); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not disable two-factor authentication"})
+		return
+	}
+	h.audit(context, models.AuditEvent{Type: models.AuditTwoFactorOff, UserID: user.ID, Email: user.Email, IP: context.ClientIP()})
+	context.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// regenerateBackupCodes replaces every backup code, used or not.
+func (h *handler) regenerateBackupCodes(context *gin.Context) {
+	request, ok := bindSecondFactor(context)
+	if !ok {
+		return
+	}
+	user, ok := h.currentUser(context)
+	if !ok {
+		return
+	}
+	if !user.TwoFactor.Enabled {
+		context.JSON(http.StatusConflict, gin.H{"message": "Two-factor authentication is not enabled"})
+		return
+	}
+	if !h.verifySecondFactor(context, user, request) {
+		return
+	}
+	codes, hashes, err := models.NewBackupCodes()
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create backup codes"})
+		return
+	}
+	if err := h.store.Users.SetBackupCodes(context, user.ID, hashes); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not create backup codes"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "New backup codes created, the old ones no longer work", "backup_codes": codes})
+}
+
+// requireTwoFactor lets site admins make 2FA mandatory for an account, such
+// as shelter staff or group admins. A user who has not turned 2FA on yet is
+// signed out everywhere and has to set it up at their next login.
+func (h *handler) requireTwoFactor(context *gin.Context) {
+	if !h.authorizeAdmin(context) {
+		return
+	}
+	var request twoFactorRequirement
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	userId, err := primitive.ObjectIDFromHex(context.Param("userId"))
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if errors.Is(err, models.ErrNotFound) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not change two-factor requirement"})
+		return
+	}
+	err = h.store.Users.SetTwoFactorRequired(context, userId, *request.Required)
+	if errors.Is(err, models.ErrNotFound) {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not change two-factor requirement"})
+		return
+	}
+	if *request.Required && !user.TwoFactor.Enabled {
+		if _, err := h.store.Sessions.RevokeUserSessions(context, userId); err != nil {
+			context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not sign the user out"})
+			return
+		}
+	}
+	adminId, _ := currentUserId(context)
+	h.audit(context, models.AuditEvent{Type: models.AuditTwoFactorPolicy, UserID: userId, IP: context.ClientIP(), Detail: fmt.Sprintf("required=%t by admin %s", *request.Required, adminId.Hex())})
+	context.JSON(http.StatusOK, gin.H{"message": "Two-factor requirement updated", "required": *request.Required})
+}
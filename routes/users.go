@@ -3,28 +3,39 @@ package routes
 import (
 	"net/http"
 	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func getUsers(context *gin.Context) {
-	users, err := models.FindAllUsers()
+func (h *handler) getUsers(context *gin.Context) {
+	users, err := h.store.Users.FindAllUsers(context)
 	if err != nil {
 		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find users", "error": err})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"users": users})
+	context.JSON(http.StatusOK, gin.H{"users": views.Users(users, h.viewer(context))})
 }
 
-func getUser(context *gin.Context) {
-	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
-	filter := bson.D{{Key: "_id", Value: userId}}
-	user, err := models.FindUser(filter)
+// getUser returns the user in the path; "me" stands for the caller.
+func (h *handler) getUser(context *gin.Context) {
+	userId, err := primitive.ObjectIDFromHex(context.Param("userId"))
+	if context.Param("userId") == "me" {
+		userId, err = primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	}
 	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err == models.ErrNotFound {
 		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find user"})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"user": user})
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch user"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"user": views.User(user, h.viewer(context))})
 }
@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"pet-search-backend-go/models"
+	"strings"
+	"testing"
+)
+
+// withSecrets gives user a password hash, a TOTP secret and backup code
+// hashes, the things no response may contain. 2FA is left pending so the
+// user can still log in with a password alone.
+func withSecrets(t *testing.T, ts *testServer, user models.User) (models.User, []string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := ts.store.Users.StartTwoFactor(ctx, user.ID, "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"); err != nil {
+		t.Fatal(err)
+	}
+	codes := []string{models.HashBackupCode("aaaa-bbbb"), models.HashBackupCode("cccc-dddd")}
+	if err := ts.store.Users.SetBackupCodes(ctx, user.ID, codes); err != nil {
+		t.Fatal(err)
+	}
+	user, err := ts.store.Users.FindUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, append([]string{user.Password, user.TwoFactor.Secret}, user.TwoFactor.BackupCodes...)
+}
+
+func expectNoSecrets(t *testing.T, body string, secrets []string) {
+	t.Helper()
+	for _, secret := range secrets {
+		if secret == "" {
+			t.Fatal("test user is missing a secret")
+		}
+		if strings.Contains(body, secret) {
+			t.Errorf("response contains %q: %s", secret, body)
+		}
+	}
+	for _, key := range []string{`"password"`, `"two_factor"`, `"secret"`, `"backup_codes"`} {
+		if strings.Contains(body, key) {
+			t.Errorf("response has a %s field: %s", key, body)
+		}
+	}
+}
+
+func TestUserResponsesHideSecrets(t *testing.T) {
+	ts := newTestServer(t)
+	user, userToken := ts.signUp("owner", models.RoleUser)
+	_, adminToken := ts.signUp("admin", models.RoleAdmin)
+	_, otherToken := ts.signUp("other", models.RoleUser)
+	user, secrets := withSecrets(t, ts, user)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   any
+		status int
+	}{
+		{"getUser self", http.MethodGet, "/users/me", userToken, nil, http.StatusOK},
+		{"getUser admin", http.MethodGet, "/users/" + user.ID.Hex(), adminToken, nil, http.StatusOK},
+		{"getUser public", http.MethodGet, "/users/" + user.ID.Hex(), otherToken, nil, http.StatusOK},
+		{"getUsers self", http.MethodGet, "/users/", userToken, nil, http.StatusOK},
+		{"getUsers admin", http.MethodGet, "/users/", adminToken, nil, http.StatusOK},
+		{"getUsers public", http.MethodGet, "/users/", otherToken, nil, http.StatusOK},
+		{"login", http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": testPassword}, http.StatusAccepted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := ts.do(test.method, test.path, test.token, test.body)
+			expectStatus(t, recorder, test.status)
+			expectNoSecrets(t, recorder.Body.String(), secrets)
+		})
+	}
+}
+
+func TestSignupResponseHidesPassword(t *testing.T) {
+	ts := newTestServer(t)
+	recorder := ts.do(http.MethodPost, "/auth/signup", "", map[string]string{"username": "newcomer", "email": "newcomer@example.com", "password": testPassword})
+	expectStatus(t, recorder, http.StatusCreated)
+	user, err := ts.store.Users.FindUserByEmail(context.Background(), "newcomer@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectNoSecrets(t, recorder.Body.String(), []string{user.Password})
+}
@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"pet-search-backend-go/mail"
+	"pet-search-backend-go/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// emailResendInterval is the least time between two verification or
+	// password reset emails to the same user, and maxEmailsPerDay caps them
+	// per day.
+	emailResendInterval = time.Minute
+	maxEmailsPerDay     = 5
+)
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// sendVerification issues a fresh verification token for user, which
+// invalidates any earlier one, and emails it.
+func (h *handler) sendVerification(context *gin.Context, user models.User) error {
+	token, hash, err := models.NewToken()
+	if err != nil {
+		return err
+	}
+	_, err = h.store.AccountTokens.CreateAccountToken(context, models.AccountToken{UserID: user.ID, Purpose: models.TokenEmailVerification, Hash: hash, ExpiresAt: time.Now().Add(models.EmailVerificationTTL)})
+	if err != nil {
+		return err
+	}
+	h.sendMail(mail.Message{
+		To:      user.Email,
+		Subject: "Verify your Pet Search email",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to Pet Search. Open this link within %d hours to verify your email address; "+
+			"you need a verified address to post lost and found reports:\n\n%s\n\n"+
+			"If you did not sign up, you can ignore this email.\n",
+			user.Username, int(models.EmailVerificationTTL.Hours()), h.appLink("/verify-email", token)),
+	})
+	return nil
+}
+
+// emailRetryAfter returns how long the user must wait before another email
+// carrying a token for purpose, or zero if one may be sent now.
+func (h *handler) emailRetryAfter(context *gin.Context, user models.User, purpose string) (time.Duration, error) {
+	now := time.Now()
+	recent, err := h.store.AccountTokens.RecentAccountTokens(context, user.ID, purpose, now.Add(-24*time.Hour))
+	if err != nil {
+		return 0, err
+	}
+	if len(recent) >= maxEmailsPerDay {
+		return recent[maxEmailsPerDay-1].CreatedAt.Add(24 * time.Hour).Sub(now), nil
+	}
+	if len(recent) > 0 {
+		if wait := recent[0].CreatedAt.Add(emailResendInterval).Sub(now); wait > 0 {
+			return wait, nil
+		}
+	}
+	return 0, nil
+}
+
+func (h *handler) verifyEmail(context *gin.Context) {
+	var request verifyEmailRequest
+	err := context.ShouldBindJSON(&request)
+	if err != nil {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Could not parse request data"})
+		return
+	}
+	token, err := h.store.AccountTokens.ConsumeAccountToken(context, models.TokenEmailVerification, models.HashToken(request.Token))
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusBadRequest, gin.H{"message": "Verification link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not verify email"})
+		return
+	}
+	err = h.store.Users.SetEmailVerified(context, token.UserID)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not verify email"})
+		return
+	}
+	context.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (h *handler) resendVerification(context *gin.Context) {
+	userId, ok := currentUserId(context)
+	if !ok {
+		return
+	}
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not find user"})
+		return
+	}
+	if user.EmailVerified() {
+		context.JSON(http.StatusConflict, gin.H{"message": "Email is already verified"})
+		return
+	}
+	wait, err := h.emailRetryAfter(context, user, models.TokenEmailVerification)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not send verification email"})
+		return
+	}
+	if wait > 0 {
+		seconds := int(wait.Round(time.Second).Seconds())
+		context.Header("Retry-After", strconv.Itoa(seconds))
+		context.JSON(http.StatusTooManyRequests, gin.H{"message": "Please wait before requesting another verification email", "retry_after": seconds})
+		return
+	}
+	if err := h.sendVerification(context, user); err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not send verification email"})
+		return
+	}
+	context.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
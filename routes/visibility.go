@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"net/http"
+	"pet-search-backend-go/models"
+	"pet-search-backend-go/views"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// visibility answers which posts the caller may see, caching the groups it
+// loads so a whole feed can be filtered with one lookup per group.
+type visibility struct {
+	h         *handler
+	context   *gin.Context
+	userId    primitive.ObjectID
+	moderator bool
+	groups    map[primitive.ObjectID]models.Group
+}
+
+func (h *handler) newVisibility(context *gin.Context) *visibility {
+	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	user, err := h.store.Users.FindUserByID(context, userId)
+	return &visibility{h: h, context: context, userId: userId, moderator: err == nil && user.CanModerate(), groups: map[primitive.ObjectID]models.Group{}}
+}
+
+func (v *visibility) allows(post models.Post) (bool, error) {
+	if v.moderator {
+		return true, nil
+	}
+	var groups []models.Group
+	for _, groupId := range post.Groups {
+		group, ok := v.groups[groupId]
+		if !ok {
+			var err error
+			group, err = v.h.store.Groups.FindGroup(v.context, groupId)
+			if err == models.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			v.groups[groupId] = group
+		}
+		groups = append(groups, group)
+	}
+	return post.VisibleTo(v.userId, groups), nil
+}
+
+func (v *visibility) filter(posts []models.Post) ([]models.Post, error) {
+	var visible []models.Post
+	for _, post := range posts {
+		ok, err := v.allows(post)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visible = append(visible, post)
+		}
+	}
+	return visible, nil
+}
+
+// findVisiblePost loads the post and hides it behind a 404 when the caller is
+// not allowed to see it.
+func (h *handler) findVisiblePost(context *gin.Context, postId primitive.ObjectID) (models.Post, bool) {
+	post, err := h.store.Posts.FindPost(context, postId)
+	if err == models.ErrNotFound {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find post"})
+		return models.Post{}, false
+	}
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch post"})
+		return models.Post{}, false
+	}
+	ok, err := h.newVisibility(context).allows(post)
+	if err != nil {
+		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not fetch post"})
+		return models.Post{}, false
+	}
+	if !ok {
+		context.JSON(http.StatusNotFound, gin.H{"message": "Could not find post"})
+		return models.Post{}, false
+	}
+	return post, true
+}
+
+// viewer identifies the caller for rendering responses through views.
+func (h *handler) viewer(context *gin.Context) views.Viewer {
+	userId, _ := primitive.ObjectIDFromHex(context.Request.Header.Get("userId"))
+	user, err := h.store.Users.FindUserByID(context, userId)
+	if err != nil {
+		return views.Viewer{ID: userId}
+	}
+	return views.NewViewer(user)
+}
@@ -0,0 +1,41 @@
+package views
+
+import (
+	"pet-search-backend-go/models"
+)
+
+// Group shows a private group's members only to its members, and pending
+// requests and invitations only to the group's owner and admins. Site admins
+// see everything.
+func Group(group models.Group, viewer Viewer) models.Group {
+	if viewer.Admin() || group.HasRole(viewer.ID, models.GroupRoleOwner, models.GroupRoleAdmin) {
+		return group
+	}
+	// Other users only see their own pending request or invitation.
+	var requests []models.JoinRequest
+	for _, r := range group.Requests {
+		if r.UserID == viewer.ID {
+			requests = append(requests, r)
+		}
+	}
+	var invitations []models.Invitation
+	for _, i := range group.Invitations {
+		if i.UserID == viewer.ID {
+			invitations = append(invitations, i)
+		}
+	}
+	group.Requests = requests
+	group.Invitations = invitations
+	if group.Private && group.RoleOf(viewer.ID) == "" {
+		group.Members = nil
+	}
+	return group
+}
+
+func Groups(groups []models.Group, viewer Viewer) []models.Group {
+	result := []models.Group{}
+	for _, group := range groups {
+		result = append(result, Group(group, viewer))
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package views
+
+import (
+	"pet-search-backend-go/models"
+)
+
+// Post hides the pet's microchip number and the status history from anyone
+// but the author and moderators. A chip number is what a scammer would quote
+// to "prove" they have the pet, so only the owner should know it.
+func Post(post models.Post, viewer Viewer) models.Post {
+	if post.Creator == viewer.ID || viewer.Moderator() {
+		return post
+	}
+	if post.Pet != nil {
+		pet := *post.Pet
+		pet.Microchip = ""
+		post.Pet = &pet
+	}
+	post.StatusHistory = nil
+	return post
+}
+
+func Posts(posts []models.Post, viewer Viewer) []models.Post {
+	result := []models.Post{}
+	for _, post := range posts {
+		result = append(result, Post(post, viewer))
+	}
+	return result
+}
+
+func NearbyPosts(posts []models.NearbyPost, viewer Viewer) []models.NearbyPost {
+	result := []models.NearbyPost{}
+	for _, post := range posts {
+		result = append(result, models.NearbyPost{Post: Post(post.Post, viewer), Distance: post.Distance})
+	}
+	return result
+}
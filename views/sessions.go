@@ -0,0 +1,22 @@
+package views
+
+import (
+	"pet-search-backend-go/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session marks whether a listed session is the one making the request, so
+// clients can label it as this device.
+type Session struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+func Sessions(sessions []models.Session, currentId primitive.ObjectID) []Session {
+	views := []Session{}
+	for _, session := range sessions {
+		views = append(views, Session{Session: session, Current: session.ID == currentId})
+	}
+	return views
+}
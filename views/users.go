@@ -0,0 +1,67 @@
+package views
+
+import (
+	"pet-search-backend-go/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PublicUser is what anyone may see about a user.
+type PublicUser struct {
+	ID        primitive.ObjectID `json:"_id"`
+	Username  string             `json:"username"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// AdminUser adds the contact and membership details site admins need.
+type AdminUser struct {
+	PublicUser
+	Email             string               `json:"email"`
+	EmailVerified     bool                 `json:"email_verified"`
+	PhoneNumber       string               `json:"phone_number"`
+	Role              string               `json:"role"`
+	MemberOf          []primitive.ObjectID `json:"member_of"`
+	TwoFactorEnabled  bool                 `json:"two_factor_enabled"`
+	TwoFactorRequired bool                 `json:"two_factor_required"`
+}
+
+// SelfUser is a user's view of their own account.
+type SelfUser struct {
+	AdminUser
+	Posts              []models.Post        `json:"posts"`
+	MutedNotifications []string             `json:"muted_notifications"`
+	BlockedUsers       []primitive.ObjectID `json:"blocked_users"`
+}
+
+func public(user models.User) PublicUser {
+	return PublicUser{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt}
+}
+
+func admin(user models.User) AdminUser {
+	return AdminUser{PublicUser: public(user), Email: user.Email, EmailVerified: user.EmailVerified(), PhoneNumber: user.PhoneNumber, Role: user.Role, MemberOf: user.MemberOf, TwoFactorEnabled: user.TwoFactor.Enabled, TwoFactorRequired: user.TwoFactorRequired}
+}
+
+func Self(user models.User) SelfUser {
+	viewer := NewViewer(user)
+	return SelfUser{AdminUser: admin(user), Posts: Posts(user.Posts, viewer), MutedNotifications: user.MutedNotifications, BlockedUsers: user.BlockedUsers}
+}
+
+// User picks the self, admin or public view of the user for the viewer.
+func User(user models.User, viewer Viewer) any {
+	switch {
+	case user.ID == viewer.ID:
+		return Self(user)
+	case viewer.Admin():
+		return admin(user)
+	}
+	return public(user)
+}
+
+func Users(users []models.User, viewer Viewer) []any {
+	result := []any{}
+	for _, user := range users {
+		result = append(result, User(user, viewer))
+	}
+	return result
+}
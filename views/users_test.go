@@ -0,0 +1,61 @@
+package views
+
+import (
+	"encoding/json"
+	"pet-search-backend-go/models"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUserViewsHideSecrets(t *testing.T) {
+	enabledAt := time.Now()
+	user := models.User{
+		ID:       primitive.NewObjectID(),
+		Username: "owner",
+		Email:    "owner@example.com",
+		Password: "$2a$10$passwordhashpasswordhashpasswordhashpasswordhash",
+		Role:     models.RoleUser,
+		TwoFactor: models.TwoFactor{
+			Secret:      "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP",
+			Enabled:     true,
+			EnabledAt:   &enabledAt,
+			BackupCodes: []string{"backup-code-hash-1", "backup-code-hash-2"},
+		},
+	}
+	secrets := append([]string{user.Password, user.TwoFactor.Secret}, user.TwoFactor.BackupCodes...)
+	admin := Viewer{ID: primitive.NewObjectID(), Role: models.RoleAdmin}
+	other := Viewer{ID: primitive.NewObjectID(), Role: models.RoleUser}
+
+	tests := []struct {
+		name string
+		view any
+	}{
+		{"self", User(user, NewViewer(user))},
+		{"admin", User(user, admin)},
+		{"public", User(user, other)},
+		{"list", Users([]models.User{user}, admin)},
+		{"model", user},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.view)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body := string(data)
+			for _, secret := range secrets {
+				if strings.Contains(body, secret) {
+					t.Errorf("view contains %q: %s", secret, body)
+				}
+			}
+			for _, key := range []string{`"password"`, `"two_factor"`, `"secret"`, `"backup_codes"`} {
+				if strings.Contains(body, key) {
+					t.Errorf("view has a %s field: %s", key, body)
+				}
+			}
+		})
+	}
+}
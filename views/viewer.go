@@ -0,0 +1,28 @@
+// Package views maps models to API responses. Every user, post and group
+// leaving the API goes through here, so fields are only exposed to viewers
+// allowed to see them.
+package views
+
+import (
+	"pet-search-backend-go/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Viewer is the user a response is rendered for.
+type Viewer struct {
+	ID   primitive.ObjectID
+	Role string
+}
+
+func NewViewer(user models.User) Viewer {
+	return Viewer{ID: user.ID, Role: user.Role}
+}
+
+func (v Viewer) Admin() bool {
+	return v.Role == models.RoleAdmin
+}
+
+func (v Viewer) Moderator() bool {
+	return v.Role == models.RoleModerator || v.Role == models.RoleAdmin
+}